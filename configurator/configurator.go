@@ -3,7 +3,11 @@ package configurator
 import (
 	web "github.com/edlingao/hexago/common/delivery/web"
 	views "github.com/edlingao/hexago/web/views"
+	"log"
+	"net"
 	"os"
+	"strings"
+	"time"
 
 	auth "github.com/edlingao/go-auth/auth/core"
 	usersAdapter "github.com/edlingao/hexago/internal/users/adapters"
@@ -20,6 +24,10 @@ type Configurator struct {
 	Echo           *echo.Echo
 	v1             *echo.Group
 	root           *echo.Group
+	recovery       *usersAdapter.AccountRecovery
+	twoFactor      *usersAdapter.TwoFactor
+	throttle       *usersAdapter.LoginThrottle
+	payments       usersPorts.TakingPayments
 }
 
 func New(
@@ -31,6 +39,8 @@ func New(
 
 	root := echo.Group("")
 
+	echo.IPExtractor = ipExtractor()
+
 	return &Configurator{
 		Echo: echo,
 		v1:   v1,
@@ -38,6 +48,42 @@ func New(
 	}
 }
 
+// ipExtractor decides where c.RealIP() comes from. The login throttle keys
+// on it, so X-Forwarded-For is only read when it was set by one of the
+// proxies in TRUSTED_PROXIES (comma-separated CIDRs); otherwise it is the
+// peer address.
+func ipExtractor() echo.IPExtractor {
+	var trust []echo.TrustOption
+	for _, cidr := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Printf("TRUSTED_PROXIES: ignoring %q: %v", cidr, err)
+			continue
+		}
+		trust = append(trust, echo.TrustIPRange(ipNet))
+	}
+	if len(trust) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	trust = append(trust, echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false))
+	return echo.ExtractIPFromXFFHeader(trust...)
+}
+
+// AddAuth resolves the caller's session on every request. Register it
+// before any route that reads the principal.
+func (c *Configurator) AddAuth() *Configurator {
+	db, err := usersAdapter.ConnectDB()
+	if err != nil {
+		panic(err)
+	}
+	c.Echo.Use(usersAdapter.NewAuthMiddleware(db).Resolve)
+	return c
+}
+
 func (c *Configurator) AddCalculatorAPI() *Configurator {
 
 	return c
@@ -52,11 +98,11 @@ func (c *Configurator) AddUserAPI() *Configurator {
 	dbService := usersAdapter.NewDB[usersCore.User]()
 	sessionDBService := usersAdapter.NewSessionStore[auth.Session]()
 
-	userService := usersPorts.NewUserService(dbService)
+	userService := usersPorts.NewUserService(dbService, usersAdapter.NewBcryptHasher())
 	usersHttpService := c.v1.Group("/users")
 	sessionService := auth.NewSessionService(
 		sessionDBService,
-		"Auth", // Cookie name or header name
+		"session_token", // Cookie name or header name
 	)
 
 	userAPIHandler := usersAdapter.NewUsersAPIService(
@@ -66,6 +112,11 @@ func (c *Configurator) AddUserAPI() *Configurator {
 		userService,
 	)
 
+	userAPIHandler.
+		WithAccountRecovery(c.accountRecovery()).
+		WithTwoFactor(c.sharedTwoFactor()).
+		WithLoginThrottle(c.loginThrottle())
+
 	c.UserAPIHandler = *userAPIHandler
 
 	return c
@@ -75,11 +126,11 @@ func (c *Configurator) AddUserWeb() *Configurator {
 	dbService := usersAdapter.NewDB[usersCore.User]()
 	sessionDBService := usersAdapter.NewSessionStore[auth.Session]()
 
-	userService := usersPorts.NewUserService(dbService)
+	userService := usersPorts.NewUserService(dbService, usersAdapter.NewBcryptHasher())
 	usersHttpService := c.root
 	sessionService := auth.NewSessionService(
 		sessionDBService,
-		"Auth", // Cookie name or header name
+		"session_token", // Cookie name or header name
 	)
 
 	usersWebPage := usersAdapter.NewUsersWebService(
@@ -89,6 +140,9 @@ func (c *Configurator) AddUserWeb() *Configurator {
 		dbService,
 		userService,
 	)
+	usersWebPage.
+		WithTwoFactor(c.sharedTwoFactor()).
+		WithLoginThrottle(c.loginThrottle())
 	c.UserWebPage = *usersWebPage
 
 	return c
@@ -152,7 +206,7 @@ func (c *Configurator) AddMeAPI() *Configurator {
 	if err != nil {
 		panic(err)
 	}
-	meAPI := usersAdapter.NewMeAPIService(c.v1).WithDB(db)
+	meAPI := usersAdapter.NewMeAPIService(c.v1).WithDB(db).WithPayments(c.sharedPayments())
 	meAPI.RegisterRoutes()
 	return c
 }
@@ -172,7 +226,84 @@ func (c *Configurator) AddAdminAPI() *Configurator {
 	if err != nil {
 		panic(err)
 	}
-	admin := usersAdapter.NewAdminAPIService(c.v1).WithDB(db)
+	admin := usersAdapter.NewAdminAPIService(c.v1).WithDB(db).WithPayments(c.sharedPayments())
 	admin.RegisterRoutes()
 	return c
 }
+
+// accountRecovery is shared by the sign-up API and the reset/verify routes.
+func (c *Configurator) accountRecovery() *usersAdapter.AccountRecovery {
+	if c.recovery == nil {
+		db, err := usersAdapter.ConnectDB()
+		if err != nil {
+			panic(err)
+		}
+		c.recovery = usersAdapter.NewAccountRecovery(db, usersAdapter.NewMailerFromEnv(), usersAdapter.NewBcryptHasher())
+	}
+	return c.recovery
+}
+
+func (c *Configurator) sharedTwoFactor() *usersAdapter.TwoFactor {
+	if c.twoFactor == nil {
+		db, err := usersAdapter.ConnectDB()
+		if err != nil {
+			panic(err)
+		}
+		c.twoFactor = usersAdapter.NewTwoFactor(db)
+	}
+	return c.twoFactor
+}
+
+func (c *Configurator) loginThrottle() *usersAdapter.LoginThrottle {
+	if c.throttle == nil {
+		db, err := usersAdapter.ConnectDB()
+		if err != nil {
+			panic(err)
+		}
+		c.throttle = usersAdapter.NewLoginThrottle(db)
+	}
+	return c.throttle
+}
+
+// sharedPayments is one provider for every service, so the in-process fake
+// keeps a single set of customers and charges.
+func (c *Configurator) sharedPayments() usersPorts.TakingPayments {
+	if c.payments == nil {
+		c.payments = usersAdapter.NewPaymentsFromEnv()
+	}
+	return c.payments
+}
+
+func (c *Configurator) AddPaymentsWebhook() *Configurator {
+	db, err := usersAdapter.ConnectDB()
+	if err != nil {
+		panic(err)
+	}
+	usersAdapter.NewPaymentsWebhookService(c.v1, db, c.sharedPayments()).RegisterRoutes()
+	return c
+}
+
+func (c *Configurator) AddAccountRecovery() *Configurator {
+	usersAdapter.NewAccountAPIService(c.v1, c.accountRecovery()).RegisterRoutes()
+	usersAdapter.NewAccountWebService(c.root, c.accountRecovery()).RegisterRoutes()
+	return c
+}
+
+// AddBillingRenewals charges due subscriptions every hour.
+func (c *Configurator) AddBillingRenewals() *Configurator {
+	db, err := usersAdapter.ConnectDB()
+	if err != nil {
+		panic(err)
+	}
+	usersAdapter.NewRenewalEngine(db, c.sharedPayments()).Start(time.Hour)
+	return c
+}
+
+func (c *Configurator) AddSessionJanitor() *Configurator {
+	db, err := usersAdapter.ConnectDB()
+	if err != nil {
+		panic(err)
+	}
+	usersAdapter.StartSessionJanitor(db, time.Hour)
+	return c
+}
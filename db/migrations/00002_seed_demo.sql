@@ -16,11 +16,12 @@ ON CONFLICT (id) DO UPDATE SET
   name=EXCLUDED.name,
   address=EXCLUDED.address;
 
+-- Passwords are bcrypt hashes (demo: demo, others: demo123).
 INSERT INTO users (id,username,password,email,first_name,last_name,avatar_url) VALUES
-(1,'demo','demo','','Demo','User',''),
-(4,'mia','demo123','mia.torres@hedgestonecarwash.com','Mia','Torres','https://i.pravatar.cc/150?img=47'),
-(5,'carlos','demo123','carlos.mendoza@hedgestonecarwash.com','Carlos','Mendoza','https://i.pravatar.cc/150?img=12'),
-(6,'priya','demo123','priya.patel@hedgestonecarwash.com','Priya','Patel','https://i.pravatar.cc/150?img=5')
+(1,'demo','$2a$10$gRD7k7YADqMt3b8p8GIvTeeYv6f7NJivySxlxL5UcIRCsmF/kKLBm','','Demo','User',''),
+(4,'mia','$2a$10$qT6.ScfVLZUcNpCE0yNl9eLMMsqIHJyLhPaNOZn0eRo2L8r5wKngu','mia.torres@hedgestonecarwash.com','Mia','Torres','https://i.pravatar.cc/150?img=47'),
+(5,'carlos','$2a$10$qT6.ScfVLZUcNpCE0yNl9eLMMsqIHJyLhPaNOZn0eRo2L8r5wKngu','carlos.mendoza@hedgestonecarwash.com','Carlos','Mendoza','https://i.pravatar.cc/150?img=12'),
+(6,'priya','$2a$10$qT6.ScfVLZUcNpCE0yNl9eLMMsqIHJyLhPaNOZn0eRo2L8r5wKngu','priya.patel@hedgestonecarwash.com','Priya','Patel','https://i.pravatar.cc/150?img=5')
 ON CONFLICT (id) DO UPDATE SET
   username=EXCLUDED.username,
   password=EXCLUDED.password,
@@ -1,6 +1,7 @@
 -- +goose Up
+-- Password is a bcrypt hash of admin123.
 INSERT INTO users (id, username, password, email, first_name, last_name, avatar_url)
-VALUES (2, 'admin', 'admin123', 'admin@hedgestonecarwash.com', 'Admin', 'User', '')
+VALUES (2, 'admin', '$2a$10$F/efHP5iKlnvqZalQDh6Z.lazJnD1kVjtEjWCO0P.i4AAwNLKlsTK', 'admin@hedgestonecarwash.com', 'Admin', 'User', '')
 ON CONFLICT (id) DO UPDATE SET
   username=EXCLUDED.username,
   password=EXCLUDED.password,
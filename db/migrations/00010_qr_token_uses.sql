@@ -0,0 +1,19 @@
+-- +goose Up
+-- One row per redeemed QR nonce; the primary key makes redemption single-use.
+CREATE TABLE IF NOT EXISTS qr_token_uses (
+  nonce TEXT PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  issued_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_qr_token_uses_used_at ON qr_token_uses(used_at);
+
+-- Forged QR codes carry no trustworthy user, so denied events may have none.
+ALTER TABLE wash_events ALTER COLUMN user_id DROP NOT NULL;
+
+-- +goose Down
+DELETE FROM wash_events WHERE user_id IS NULL;
+ALTER TABLE wash_events ALTER COLUMN user_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_qr_token_uses_used_at;
+DROP TABLE IF EXISTS qr_token_uses;
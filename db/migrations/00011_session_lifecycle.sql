@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE sessions
+  ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() + INTERVAL '7 days'),
+  ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '';
+
+-- Existing sessions get the same 7 day lifetime the cookie always advertised.
+UPDATE sessions
+SET expires_at = created_at + INTERVAL '7 days',
+    last_seen_at = created_at;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sessions_expires_at;
+DROP INDEX IF EXISTS idx_sessions_user_id;
+ALTER TABLE sessions
+  DROP COLUMN IF EXISTS ip,
+  DROP COLUMN IF EXISTS user_agent,
+  DROP COLUMN IF EXISTS last_seen_at,
+  DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,47 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS roles (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  built_in BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id TEXT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  permission TEXT NOT NULL,
+  PRIMARY KEY (role_id, permission)
+);
+
+INSERT INTO roles (id, name, description, built_in) VALUES
+('admin', 'Administrator', 'Full access to the admin portal', TRUE),
+('attendant', 'Attendant', 'Scans member codes at the lane', TRUE),
+('member', 'Member', 'Regular subscriber', TRUE)
+ON CONFLICT (id) DO UPDATE SET built_in = TRUE;
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+('admin', 'scan.perform'),
+('admin', 'members.read'),
+('admin', 'members.delete'),
+('admin', 'plans.read'),
+('admin', 'plans.write'),
+('admin', 'locations.read'),
+('admin', 'locations.write'),
+('admin', 'stats.read'),
+('admin', 'audit.read'),
+('admin', 'roles.manage'),
+('attendant', 'scan.perform')
+ON CONFLICT DO NOTHING;
+
+-- Keep any ad-hoc role strings already in use, with no permissions.
+INSERT INTO roles (id, name)
+SELECT DISTINCT role, role FROM users
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE users
+  ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(id) ON UPDATE CASCADE;
+
+-- +goose Down
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
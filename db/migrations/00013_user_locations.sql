@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_locations (
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  location_id TEXT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, location_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_locations_location_id ON user_locations(location_id);
+
+-- Callers without locations.all only see the locations assigned to them.
+INSERT INTO role_permissions (role_id, permission) VALUES
+('admin', 'locations.all')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO roles (id, name, description, built_in) VALUES
+('manager', 'Site manager', 'Stats, members and scans for assigned locations', TRUE)
+ON CONFLICT (id) DO UPDATE SET built_in = TRUE;
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+('manager', 'scan.perform'),
+('manager', 'members.read'),
+('manager', 'locations.read'),
+('manager', 'plans.read'),
+('manager', 'stats.read')
+ON CONFLICT DO NOTHING;
+
+-- Existing attendants keep scanning everywhere until an admin narrows them down.
+INSERT INTO user_locations (user_id, location_id)
+SELECT u.id, l.id
+FROM users u
+CROSS JOIN locations l
+WHERE u.role = 'attendant'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+UPDATE users SET role = 'member' WHERE role = 'manager';
+DELETE FROM roles WHERE id = 'manager';
+DELETE FROM role_permissions WHERE permission = 'locations.all';
+DROP TABLE IF EXISTS user_locations;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Failed sign-in counters. scope is 'user' (key = users.id) or 'ip'.
+CREATE TABLE IF NOT EXISTS login_throttle (
+  scope TEXT NOT NULL,
+  key TEXT NOT NULL,
+  failures INT NOT NULL DEFAULT 0,
+  last_failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  locked_until TIMESTAMPTZ NULL,
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttle_last_failed_at ON login_throttle(last_failed_at);
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+('admin', 'members.unlock')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'members.unlock';
+DROP TABLE IF EXISTS login_throttle;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- An impersonation session belongs to the member (user_id) but was started
+-- by impersonator_id. Such sessions have a hard expiry and never slide.
+ALTER TABLE sessions
+  ADD COLUMN IF NOT EXISTS impersonator_id BIGINT NULL REFERENCES users(id) ON DELETE CASCADE,
+  ADD COLUMN IF NOT EXISTS impersonation_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_sessions_impersonator_id ON sessions(impersonator_id) WHERE impersonator_id IS NOT NULL;
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+('admin', 'members.impersonate')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'members.impersonate';
+DELETE FROM sessions WHERE impersonator_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_sessions_impersonator_id;
+ALTER TABLE sessions
+  DROP COLUMN IF EXISTS impersonation_reason,
+  DROP COLUMN IF EXISTS impersonator_id;
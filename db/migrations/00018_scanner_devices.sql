@@ -0,0 +1,26 @@
+-- +goose Up
+-- Registered scanner kiosks. Each is bound to one location and signs in with
+-- an API key (only the sha256 is stored) or a client certificate fingerprint.
+CREATE TABLE IF NOT EXISTS scanner_devices (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  location_id TEXT NOT NULL REFERENCES locations(id),
+  api_key_hash TEXT NOT NULL UNIQUE,
+  key_prefix TEXT NOT NULL,
+  cert_fingerprint TEXT NULL UNIQUE,
+  created_by BIGINT NULL REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_seen_at TIMESTAMPTZ NULL,
+  last_seen_ip TEXT NULL,
+  revoked_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_scanner_devices_location_id ON scanner_devices(location_id);
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+('admin', 'devices.manage')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'devices.manage';
+DROP TABLE IF EXISTS scanner_devices;
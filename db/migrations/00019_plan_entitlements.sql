@@ -0,0 +1,6 @@
+-- +goose Up
+-- Structured wash rules per plan (core.PlanEntitlements). '{}' is unlimited.
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS entitlements_json TEXT NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE plans DROP COLUMN IF EXISTS entitlements_json;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Decisions for scans sent with an idempotency key. status is 0 while the
+-- first request is still being processed.
+CREATE TABLE IF NOT EXISTS scan_requests (
+  scope TEXT NOT NULL,
+  key TEXT NOT NULL,
+  status INT NOT NULL DEFAULT 0,
+  response_json TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scan_requests_created_at ON scan_requests(created_at);
+CREATE INDEX IF NOT EXISTS idx_wash_events_user_allowed ON wash_events(user_id, scanned_at) WHERE result = 'allowed';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_wash_events_user_allowed;
+DROP TABLE IF EXISTS scan_requests;
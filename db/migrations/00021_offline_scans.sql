@@ -0,0 +1,12 @@
+-- +goose Up
+-- Scans decided on a scanner while offline and uploaded later. scanned_at keeps
+-- the original time; client_event_id makes re-uploads harmless.
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS offline BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS client_event_id TEXT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wash_events_client_event_id ON wash_events(client_event_id) WHERE client_event_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_wash_events_client_event_id;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS client_event_id;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS offline;
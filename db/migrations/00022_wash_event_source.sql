@@ -0,0 +1,19 @@
+-- +goose Up
+-- Who and what recorded each scan. lane is the bay/lane label at the site;
+-- a device's lane is used when the scan doesn't name one.
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS scanned_by BIGINT NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS device_id TEXT NULL REFERENCES scanner_devices(id);
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS lane TEXT NULL;
+
+ALTER TABLE scanner_devices ADD COLUMN IF NOT EXISTS lane TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_wash_events_scanned_by ON wash_events(scanned_by, scanned_at) WHERE scanned_by IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_wash_events_device_id ON wash_events(device_id, scanned_at) WHERE device_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_wash_events_device_id;
+DROP INDEX IF EXISTS idx_wash_events_scanned_by;
+ALTER TABLE scanner_devices DROP COLUMN IF EXISTS lane;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS lane;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS device_id;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS scanned_by;
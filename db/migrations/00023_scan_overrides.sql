@@ -0,0 +1,29 @@
+-- +goose Up
+-- Attendant overrides of denied scans (result 'allowed_override', linked to the
+-- denied event) and comp washes for non-members (result 'comp').
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS override_of TEXT NULL REFERENCES wash_events(id);
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS reason_code TEXT NULL;
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS note TEXT NULL;
+
+-- A denied scan can only be overridden once.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wash_events_override_of ON wash_events(override_of) WHERE override_of IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_wash_events_reason_code ON wash_events(scanned_at) WHERE reason_code IS NOT NULL;
+
+-- Overridden washes count against the member's plan like allowed ones.
+DROP INDEX IF EXISTS idx_wash_events_user_allowed;
+CREATE INDEX IF NOT EXISTS idx_wash_events_user_allowed ON wash_events(user_id, scanned_at) WHERE result IN ('allowed', 'allowed_override');
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+('admin', 'scan.override'),
+('manager', 'scan.override')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'scan.override';
+DROP INDEX IF EXISTS idx_wash_events_user_allowed;
+CREATE INDEX IF NOT EXISTS idx_wash_events_user_allowed ON wash_events(user_id, scanned_at) WHERE result = 'allowed';
+DROP INDEX IF EXISTS idx_wash_events_reason_code;
+DROP INDEX IF EXISTS idx_wash_events_override_of;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS note;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS reason_code;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS override_of;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Scanner lookups by plate or VIN compare separator-free, upper-case values.
+CREATE INDEX IF NOT EXISTS idx_cars_plate_normalized ON cars (UPPER(REGEXP_REPLACE(plate, '[^A-Za-z0-9]', '', 'g')));
+CREATE INDEX IF NOT EXISTS idx_cars_vin_normalized ON cars (UPPER(REPLACE(vin, ' ', '')));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_cars_vin_normalized;
+DROP INDEX IF EXISTS idx_cars_plate_normalized;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Subscriptions cover one car. car_id is NULL for account-wide subscriptions
+-- from before per-vehicle plans; those still cover all of the member's cars.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS car_id TEXT NULL REFERENCES cars(id) ON DELETE SET NULL;
+-- Price locked in when the car was subscribed; NULL means the plan price.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS price_cents INT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_subscriptions_car_active ON subscriptions(car_id) WHERE car_id IS NOT NULL AND status = 'active';
+
+-- Family pricing: each additional car a member puts on the plan costs this.
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS extra_car_price_cents INT NULL;
+
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS car_id TEXT NULL REFERENCES cars(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_wash_events_car_id ON wash_events(car_id, scanned_at) WHERE car_id IS NOT NULL;
+
+-- Members with a single car: bind their subscription to it.
+UPDATE subscriptions s
+SET car_id = c.id
+FROM cars c
+WHERE c.user_id = s.user_id
+  AND s.car_id IS NULL
+  AND (SELECT COUNT(*) FROM cars c2 WHERE c2.user_id = s.user_id) = 1;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_wash_events_car_id;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS car_id;
+ALTER TABLE plans DROP COLUMN IF EXISTS extra_car_price_cents;
+DROP INDEX IF EXISTS ux_subscriptions_car_active;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS price_cents;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS car_id;
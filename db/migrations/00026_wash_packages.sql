@@ -0,0 +1,59 @@
+-- +goose Up
+-- Wash packages the site sells. price_cents is the walk-in price;
+-- member_upcharge_cents is what a member pays when their plan doesn't include it.
+CREATE TABLE IF NOT EXISTS wash_packages (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  price_cents INT NOT NULL DEFAULT 0,
+  member_upcharge_cents INT NOT NULL DEFAULT 0,
+  sort_order INT NOT NULL DEFAULT 0,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO wash_packages (id, name, description, price_cents, member_upcharge_cents, sort_order) VALUES
+('basic', 'Basic', 'Exterior wash and dry', 1200, 0, 10),
+('premium', 'Premium', 'Basic plus tire shine and spot-free rinse', 1800, 600, 20),
+('ceramic', 'Ceramic', 'Premium plus ceramic sealant', 2800, 1200, 30),
+('interior', 'Interior', 'Vacuum, wipe-down and windows inside', 2500, 1000, 40)
+ON CONFLICT (id) DO NOTHING;
+
+-- Packages included in a plan at no extra cost. The first by sort order is
+-- the plan's default wash.
+CREATE TABLE IF NOT EXISTS plan_packages (
+  plan_id TEXT NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  package_id TEXT NOT NULL REFERENCES wash_packages(id) ON DELETE CASCADE,
+  PRIMARY KEY (plan_id, package_id)
+);
+
+-- Plans that listed packages in their entitlements keep exactly those.
+INSERT INTO plan_packages (plan_id, package_id)
+SELECT p.id, pkg.value
+FROM plans p
+CROSS JOIN LATERAL jsonb_array_elements_text(COALESCE(p.entitlements_json::jsonb -> 'packageIds', '[]'::jsonb)) AS pkg(value)
+JOIN wash_packages wp ON wp.id = pkg.value
+ON CONFLICT DO NOTHING;
+
+-- Every other plan gets the basic wash, and the demo tiers their matching ones.
+INSERT INTO plan_packages (plan_id, package_id)
+SELECT p.id, 'basic' FROM plans p
+WHERE NOT EXISTS (SELECT 1 FROM plan_packages pp WHERE pp.plan_id = p.id)
+ON CONFLICT DO NOTHING;
+INSERT INTO plan_packages (plan_id, package_id)
+SELECT t.plan_id, t.package_id
+FROM (VALUES ('premium', 'premium'), ('platinum', 'premium'), ('platinum', 'ceramic'), ('platinum', 'interior')) AS t(plan_id, package_id)
+JOIN plans p ON p.id = t.plan_id
+WHERE NOT (p.entitlements_json::jsonb ? 'packageIds')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS package_id TEXT NULL REFERENCES wash_packages(id);
+ALTER TABLE wash_events ADD COLUMN IF NOT EXISTS upcharge_cents INT NULL;
+CREATE INDEX IF NOT EXISTS idx_wash_events_package ON wash_events(location_id, package_id) WHERE package_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_wash_events_package;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS upcharge_cents;
+ALTER TABLE wash_events DROP COLUMN IF EXISTS package_id;
+DROP TABLE IF EXISTS plan_packages;
+DROP TABLE IF EXISTS wash_packages;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Dunning state. A failed renewal moves a subscription to past_due; it is
+-- retried until the grace period after past_due_since runs out.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS past_due_since TIMESTAMPTZ NULL;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS renewal_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS last_renewal_attempt_at TIMESTAMPTZ NULL;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS canceled_at TIMESTAMPTZ NULL;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_due ON subscriptions(next_billing_date) WHERE status IN ('active', 'past_due');
+
+-- Every status change and renewal of a subscription, newest last.
+CREATE TABLE IF NOT EXISTS subscription_history (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id TEXT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id),
+  event TEXT NOT NULL,
+  from_status TEXT NOT NULL DEFAULT '',
+  to_status TEXT NOT NULL DEFAULT '',
+  amount_cents INT NULL,
+  charge_id TEXT NULL,
+  detail TEXT NOT NULL DEFAULT '',
+  period_start TEXT NULL,
+  period_end TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_subscription_history_sub ON subscription_history(subscription_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_subscription_history_user ON subscription_history(user_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS subscription_history;
+DROP INDEX IF EXISTS idx_subscriptions_due;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS canceled_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS last_renewal_attempt_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS renewal_attempts;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS past_due_since;
@@ -0,0 +1,51 @@
+-- +goose Up
+-- The member's customer record at the payment provider.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS payment_customer_id TEXT NULL;
+
+-- Cards saved with the provider; only display details are stored.
+CREATE TABLE IF NOT EXISTS payment_methods (
+  id TEXT PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  brand TEXT NOT NULL DEFAULT '',
+  last4 TEXT NOT NULL DEFAULT '',
+  exp_month INT NOT NULL DEFAULT 0,
+  exp_year INT NOT NULL DEFAULT 0,
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_payment_methods_default ON payment_methods(user_id) WHERE is_default;
+
+-- Every charge attempt, declined ones included.
+CREATE TABLE IF NOT EXISTS payments (
+  id TEXT PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id),
+  subscription_id TEXT NULL REFERENCES subscriptions(id) ON DELETE SET NULL,
+  provider_charge_id TEXT NULL UNIQUE,
+  amount_cents INT NOT NULL,
+  refunded_cents INT NOT NULL DEFAULT 0,
+  currency TEXT NOT NULL,
+  status TEXT NOT NULL,
+  failure_reason TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_payments_user ON payments(user_id, created_at);
+
+-- Webhook deliveries already applied; providers retry and may send twice.
+CREATE TABLE IF NOT EXISTS payment_webhook_events (
+  id TEXT PRIMARY KEY,
+  type TEXT NOT NULL,
+  received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+('admin', 'payments.manage')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'payments.manage';
+DROP TABLE IF EXISTS payment_webhook_events;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS payment_methods;
+ALTER TABLE users DROP COLUMN IF EXISTS payment_customer_id;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- A plan change scheduled for the next billing date; pending_price_cents is
+-- NULL for the plan's own price.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS pending_plan_id TEXT NULL REFERENCES plans(id) ON DELETE SET NULL;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS pending_price_cents INT NULL;
+-- Unused time credited by an immediate downgrade, taken off the next renewals.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS credit_cents INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS credit_cents;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS pending_price_cents;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS pending_plan_id;
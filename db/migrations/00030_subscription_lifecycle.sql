@@ -0,0 +1,21 @@
+-- +goose Up
+-- Member-initiated cancellation and vacation holds. A subscription set to
+-- cancel at the period end stays active until its next billing date.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS cancel_reason TEXT NULL;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS cancel_note TEXT NULL;
+-- Paused subscriptions resume on paused_until; next_billing_date is pushed
+-- back by the length of the pause when it starts.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS paused_at TIMESTAMPTZ NULL;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS paused_until TEXT NULL;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_paused_until ON subscriptions(paused_until) WHERE status = 'paused';
+CREATE INDEX IF NOT EXISTS idx_subscriptions_canceled_at ON subscriptions(canceled_at) WHERE status = 'canceled';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscriptions_canceled_at;
+DROP INDEX IF EXISTS idx_subscriptions_paused_until;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS paused_until;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS paused_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_note;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_reason;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_at_period_end;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- One row per span a subscription spent on a plan at a price. A plan change,
+-- pause or cancellation ends the open period; the reasons say why each one
+-- started and ended, which is what MRR movement and churn are computed from.
+CREATE TABLE IF NOT EXISTS subscription_periods (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id TEXT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id),
+  plan_id TEXT NOT NULL,
+  price_cents INT NOT NULL,
+  started_on TEXT NOT NULL,
+  ended_on TEXT NULL,
+  start_reason TEXT NOT NULL,
+  end_reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_subscription_periods_open ON subscription_periods(subscription_id) WHERE ended_on IS NULL;
+CREATE INDEX IF NOT EXISTS idx_subscription_periods_user ON subscription_periods(user_id, started_on);
+
+-- Existing subscriptions start with the one period we know about.
+INSERT INTO subscription_periods (subscription_id, user_id, plan_id, price_cents, started_on, ended_on, start_reason, end_reason)
+SELECT s.id, s.user_id, s.plan_id, COALESCE(s.price_cents, p.price_cents), s.start_date,
+       CASE s.status
+         WHEN 'canceled' THEN COALESCE(s.canceled_at::date::text, s.next_billing_date)
+         WHEN 'paused' THEN COALESCE(s.paused_at::date::text, s.start_date)
+       END,
+       'subscribed',
+       CASE s.status WHEN 'canceled' THEN 'canceled' WHEN 'paused' THEN 'paused' END
+FROM subscriptions s
+JOIN plans p ON p.id = s.plan_id
+WHERE NOT EXISTS (SELECT 1 FROM subscription_periods sp WHERE sp.subscription_id = s.id);
+
+-- +goose Down
+DROP TABLE IF EXISTS subscription_periods;
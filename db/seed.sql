@@ -14,10 +14,10 @@ INSERT OR IGNORE INTO locations (id, name, address) VALUES
 
 -- Demo users for scan testing (safe to run multiple times)
 INSERT OR IGNORE INTO users (id, username, password) VALUES
-  (1, 'demo', 'demo'),
-  (4, 'mia', 'demo123'),
-  (5, 'carlos', 'demo123'),
-  (6, 'priya', 'demo123');
+  (1, 'demo', '$2a$10$gRD7k7YADqMt3b8p8GIvTeeYv6f7NJivySxlxL5UcIRCsmF/kKLBm'),
+  (4, 'mia', '$2a$10$qT6.ScfVLZUcNpCE0yNl9eLMMsqIHJyLhPaNOZn0eRo2L8r5wKngu'),
+  (5, 'carlos', '$2a$10$qT6.ScfVLZUcNpCE0yNl9eLMMsqIHJyLhPaNOZn0eRo2L8r5wKngu'),
+  (6, 'priya', '$2a$10$qT6.ScfVLZUcNpCE0yNl9eLMMsqIHJyLhPaNOZn0eRo2L8r5wKngu');
 
 -- Demo subscriptions for scan testing (active)
 INSERT OR REPLACE INTO subscriptions (id, user_id, plan_id, status, start_date, next_billing_date, wash_count) VALUES
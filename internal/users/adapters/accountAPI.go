@@ -0,0 +1,88 @@
+package adapters
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AccountAPIService struct {
+	httpService *echo.Group
+	recovery    *AccountRecovery
+}
+
+type forgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type verifyEmailReq struct {
+	Token string `json:"token"`
+}
+
+func NewAccountAPIService(httpService *echo.Group, recovery *AccountRecovery) *AccountAPIService {
+	return &AccountAPIService{httpService: httpService, recovery: recovery}
+}
+
+func (a *AccountAPIService) RegisterRoutes() {
+	a.httpService.POST("/auth/password/forgot", a.ForgotPassword)
+	a.httpService.POST("/auth/password/reset", a.ResetPassword)
+	a.httpService.POST("/auth/email/verify", a.VerifyEmail)
+	a.httpService.POST("/me/email/verify", a.SendMyVerification, RequireAuth)
+}
+
+func (a *AccountAPIService) ForgotPassword(c echo.Context) error {
+	var req forgotPasswordReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	if err := a.recovery.RequestPasswordReset(req.Email); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not start reset"})
+	}
+	// Same answer whether or not the address is registered.
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+func (a *AccountAPIService) ResetPassword(c echo.Context) error {
+	var req resetPasswordReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	if err := a.recovery.ResetPassword(req.Token, req.Password); err != nil {
+		if errors.Is(err, errTokenInvalid) || errors.Is(err, errPasswordShort) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "reset failed"})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+func (a *AccountAPIService) VerifyEmail(c echo.Context) error {
+	var req verifyEmailReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	if err := a.recovery.VerifyEmail(req.Token); err != nil {
+		if errors.Is(err, errTokenInvalid) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "verification failed"})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+func (a *AccountAPIService) SendMyVerification(c echo.Context) error {
+	p, _ := principalFrom(c)
+	if err := a.recovery.SendVerification(p.UserID); err != nil {
+		if errors.Is(err, errNoEmail) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not send verification email"})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
@@ -0,0 +1,218 @@
+package adapters
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/edlingao/hexago/internal/users/ports"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tokenPurposePasswordReset = "password_reset"
+	tokenPurposeEmailVerify   = "email_verify"
+
+	passwordResetTTL = time.Hour
+	emailVerifyTTL   = 48 * time.Hour
+
+	minPasswordLength = 8
+)
+
+var (
+	errTokenInvalid  = errors.New("This link is invalid or has expired")
+	errPasswordShort = fmt.Errorf("Password must be at least %d characters", minPasswordLength)
+	errNoEmail       = errors.New("No email address on this account")
+)
+
+// AccountRecovery owns the password reset and email verification flows,
+// shared by the JSON API and the web pages.
+type AccountRecovery struct {
+	db      *sqlx.DB
+	mailer  ports.SendingMail
+	hasher  ports.HashingPasswords
+	baseURL string
+}
+
+func NewAccountRecovery(db *sqlx.DB, mailer ports.SendingMail, hasher ports.HashingPasswords) *AccountRecovery {
+	baseURL := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:" + os.Getenv("GO_PORT")
+	}
+	return &AccountRecovery{db: db, mailer: mailer, hasher: hasher, baseURL: baseURL}
+}
+
+func hashAccountToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueToken stores a new token for uid/purpose and invalidates any earlier
+// unused ones, so only the latest link works.
+func (r *AccountRecovery) issueToken(uid int64, purpose, email string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(tx.Rebind(`
+		UPDATE account_tokens SET used_at = NOW()
+		WHERE user_id = ? AND purpose = ? AND used_at IS NULL
+	`), uid, purpose); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(tx.Rebind(`
+		INSERT INTO account_tokens (token_hash, user_id, purpose, email, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`), hashAccountToken(token), uid, purpose, email, time.Now().UTC().Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, tx.Commit()
+}
+
+// consumeToken marks the token used and returns its owner. The UPDATE is the
+// single-use guard: a replay or an expired token matches no row.
+func consumeToken(tx *sqlx.Tx, token, purpose string) (uid int64, email string, err error) {
+	var row struct {
+		UserID int64  `db:"user_id"`
+		Email  string `db:"email"`
+	}
+	err = tx.Get(&row, tx.Rebind(`
+		UPDATE account_tokens SET used_at = NOW()
+		WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id, email
+	`), hashAccountToken(strings.TrimSpace(token)), purpose)
+	if err != nil {
+		return 0, "", errTokenInvalid
+	}
+	return row.UserID, row.Email, nil
+}
+
+// RequestPasswordReset emails a reset link if the address belongs to an
+// account. It reports success either way so callers can't probe for accounts.
+func (r *AccountRecovery) RequestPasswordReset(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil
+	}
+	var uid int64
+	if err := r.db.Get(&uid, r.db.Rebind(`SELECT id FROM users WHERE LOWER(email) = LOWER(?) LIMIT 1`), email); err != nil {
+		return nil
+	}
+
+	token, err := r.issueToken(uid, tokenPurposePasswordReset, email, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	link := r.baseURL + "/reset-password?token=" + token
+	if err := r.mailer.Send(core.MailMessage{
+		To:      email,
+		Subject: "Reset your password",
+		Body: "Someone asked to reset the password for your Hedgestone Carwash account.\n\n" +
+			"Open this link within the next hour to choose a new password:\n" + link + "\n\n" +
+			"If this wasn't you, you can ignore this email.",
+	}); err != nil {
+		log.Println("password reset mail:", err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password from a reset token and signs the user
+// out everywhere. Completing a reset also proves control of the address.
+func (r *AccountRecovery) ResetPassword(token, password string) error {
+	if len(password) < minPasswordLength {
+		return errPasswordShort
+	}
+	hash, err := r.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	uid, email, err := consumeToken(tx, token, tokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(tx.Rebind(`
+		UPDATE users
+		SET password = ?,
+		    email_verified_at = CASE WHEN LOWER(email) = LOWER(?) THEN COALESCE(email_verified_at, NOW()) ELSE email_verified_at END
+		WHERE id = ?
+	`), hash, email, uid); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(tx.Rebind(`DELETE FROM sessions WHERE user_id = ?`), uid); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SendVerification emails a verification link for the user's current address.
+func (r *AccountRecovery) SendVerification(uid int64) error {
+	var email string
+	if err := r.db.Get(&email, r.db.Rebind(`SELECT COALESCE(email,'') FROM users WHERE id = ? LIMIT 1`), uid); err != nil {
+		return err
+	}
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errNoEmail
+	}
+
+	token, err := r.issueToken(uid, tokenPurposeEmailVerify, email, emailVerifyTTL)
+	if err != nil {
+		return err
+	}
+	link := r.baseURL + "/verify-email?token=" + token
+	return r.mailer.Send(core.MailMessage{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: "Confirm this address for your Hedgestone Carwash account:\n" + link + "\n\n" +
+			"The link expires in 48 hours.",
+	})
+}
+
+// VerifyEmail marks the address verified, provided it hasn't changed since
+// the link was sent.
+func (r *AccountRecovery) VerifyEmail(token string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	uid, email, err := consumeToken(tx, token, tokenPurposeEmailVerify)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(tx.Rebind(`
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id = ? AND LOWER(email) = LOWER(?)
+	`), uid, email)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errTokenInvalid
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"errors"
+	"log"
+
+	"github.com/edlingao/hexago/common/delivery/web"
+	"github.com/edlingao/hexago/web/views/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type AccountWebService struct {
+	http     *echo.Group
+	recovery *AccountRecovery
+}
+
+func NewAccountWebService(httpService *echo.Group, recovery *AccountRecovery) *AccountWebService {
+	return &AccountWebService{http: httpService, recovery: recovery}
+}
+
+func (a *AccountWebService) RegisterRoutes() {
+	a.http.GET("/forgot-password", a.ForgotPasswordView)
+	a.http.POST("/forgot-password", a.ForgotPasswordEndpoint)
+	a.http.GET("/reset-password", a.ResetPasswordView)
+	a.http.POST("/reset-password", a.ResetPasswordEndpoint)
+	a.http.GET("/verify-email", a.VerifyEmailView)
+}
+
+func (a *AccountWebService) ForgotPasswordView(c echo.Context) error {
+	return web.Render(c, auth.ForgotPassword(auth.ForgotPasswordVM{}), 200)
+}
+
+func (a *AccountWebService) ForgotPasswordEndpoint(c echo.Context) error {
+	if err := a.recovery.RequestPasswordReset(c.FormValue("email")); err != nil {
+		log.Println("forgot password:", err)
+		return web.Render(c, auth.ForgotPassword(auth.ForgotPasswordVM{
+			Error: errors.New("Something went wrong, please try again"),
+		}), 500)
+	}
+	return web.Render(c, auth.ForgotPassword(auth.ForgotPasswordVM{Sent: true}), 200)
+}
+
+func (a *AccountWebService) ResetPasswordView(c echo.Context) error {
+	return web.Render(c, auth.ResetPassword(auth.ResetPasswordVM{Token: c.QueryParam("token")}), 200)
+}
+
+func (a *AccountWebService) ResetPasswordEndpoint(c echo.Context) error {
+	token := c.FormValue("token")
+	password := c.FormValue("password")
+
+	if password != c.FormValue("confirmPassword") {
+		return web.Render(c, auth.ResetPassword(auth.ResetPasswordVM{
+			Token: token,
+			Error: errors.New("Passwords do not match"),
+		}), 400)
+	}
+
+	if err := a.recovery.ResetPassword(token, password); err != nil {
+		if errors.Is(err, errTokenInvalid) || errors.Is(err, errPasswordShort) {
+			return web.Render(c, auth.ResetPassword(auth.ResetPasswordVM{Token: token, Error: err}), 400)
+		}
+		log.Println("reset password:", err)
+		return web.Render(c, auth.ResetPassword(auth.ResetPasswordVM{
+			Token: token,
+			Error: errors.New("Something went wrong, please try again"),
+		}), 500)
+	}
+	return web.Render(c, auth.ResetPassword(auth.ResetPasswordVM{Done: true}), 200)
+}
+
+func (a *AccountWebService) VerifyEmailView(c echo.Context) error {
+	if err := a.recovery.VerifyEmail(c.QueryParam("token")); err != nil {
+		if !errors.Is(err, errTokenInvalid) {
+			log.Println("verify email:", err)
+			err = errors.New("Something went wrong, please try again")
+		}
+		return web.Render(c, auth.VerifyEmail(auth.VerifyEmailVM{Error: err}), 400)
+	}
+	return web.Render(c, auth.VerifyEmail(auth.VerifyEmailVM{}), 200)
+}
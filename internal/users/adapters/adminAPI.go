@@ -2,10 +2,14 @@ package adapters
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/edlingao/hexago/internal/users/ports"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
@@ -14,10 +18,11 @@ import (
 type AdminAPIService struct {
 	httpService *echo.Group
 	db          *sqlx.DB
+	payments    ports.TakingPayments
 }
 
 func NewAdminAPIService(httpService *echo.Group) *AdminAPIService {
-	return &AdminAPIService{httpService: httpService}
+	return &AdminAPIService{httpService: httpService, payments: &ManualPayments{}}
 }
 
 func (a *AdminAPIService) WithDB(db *sqlx.DB) *AdminAPIService {
@@ -25,66 +30,54 @@ func (a *AdminAPIService) WithDB(db *sqlx.DB) *AdminAPIService {
 	return a
 }
 
-func (a *AdminAPIService) RegisterRoutes() {
-	g := a.httpService.Group("/admin", a.requireAdmin)
-	g.GET("/members", a.ListMembers)
-	g.GET("/members/:id", a.GetMemberDetail)
-	g.DELETE("/users/:id", a.DeleteUser)
-	g.GET("/plans", a.ListPlans)
-	g.GET("/locations", a.ListLocations)
-	g.POST("/locations", a.CreateLocation)
-	g.PUT("/locations/:id", a.UpdateLocation)
-	g.DELETE("/locations/:id", a.DeleteLocation)
-	g.POST("/plans", a.CreatePlan)
-	g.PUT("/plans/:id", a.UpdatePlan)
-	g.DELETE("/plans/:id", a.DeletePlan)
-	g.POST("/plans/:id/reassign", a.ReassignPlan)
-	g.GET("/audit", a.ListAudit)
-	g.GET("/stats", a.GetStats)
-	g.GET("/charts", a.GetCharts)
-}
-
-// Admin rule: user role must be "admin"
-func (a *AdminAPIService) requireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		if a.db == nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db not configured"})
-		}
-
-		token := ""
-		auth := c.Request().Header.Get("Authorization")
-		if strings.HasPrefix(auth, "Bearer ") {
-			token = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
-		}
-		if token == "" {
-			token = strings.TrimSpace(c.Request().Header.Get("X-Session-Token"))
-		}
-		if token == "" {
-			if ck, err := c.Cookie("session_token"); err == nil {
-				token = ck.Value
-			}
-		}
-		if token == "" {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
-		}
-
-		var uid int64
-		q := a.db.Rebind(`SELECT user_id FROM sessions WHERE token = ? LIMIT 1`)
-		if err := a.db.Get(&uid, q, token); err != nil {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
-		}
+func (a *AdminAPIService) WithPayments(payments ports.TakingPayments) *AdminAPIService {
+	a.payments = payments
+	return a
+}
 
-		var role string
-		q2 := a.db.Rebind(`SELECT role FROM users WHERE id = ? LIMIT 1`)
-		if err := a.db.Get(&role, q2, uid); err != nil {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
-		}
-		if role != "admin" {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
-		}
-		c.Set("adminUserID", uid)
-		return next(c)
-	}
+func (a *AdminAPIService) RegisterRoutes() {
+	// Every admin route declares the permission it needs.
+	g := a.httpService.Group("/admin", RequireAuth)
+	g.GET("/members", a.ListMembers, RequirePermission(core.PermMembersRead))
+	g.GET("/members/:id", a.GetMemberDetail, RequirePermission(core.PermMembersRead))
+	g.GET("/vehicles/unsubscribed", a.ListUnsubscribedVehicles, RequirePermission(core.PermMembersRead))
+	g.GET("/payments", a.ListPayments, RequirePermission(core.PermPaymentsManage))
+	g.POST("/payments/:id/refund", a.RefundPayment, RequirePermission(core.PermPaymentsManage))
+	g.DELETE("/users/:id", a.DeleteUser, RequirePermission(core.PermMembersDelete))
+	g.PUT("/users/:id/role", a.SetUserRole, RequirePermission(core.PermRolesManage))
+	g.DELETE("/users/:id/2fa", a.ResetUserTwoFactor, RequirePermission(core.PermRolesManage))
+	g.POST("/users/:id/unlock", a.UnlockUser, RequirePermission(core.PermMembersUnlock))
+	g.POST("/members/:id/impersonate", a.StartImpersonation, RequirePermission(core.PermMembersImpersonate))
+	g.GET("/users/:id/locations", a.GetUserLocations, RequirePermission(core.PermRolesManage))
+	g.PUT("/users/:id/locations", a.SetUserLocations, RequirePermission(core.PermRolesManage))
+	g.GET("/plans", a.ListPlans, RequirePermission(core.PermPlansRead))
+	g.GET("/packages", a.ListPackages, RequirePermission(core.PermPlansRead))
+	g.POST("/packages", a.CreatePackage, RequirePermission(core.PermPlansWrite))
+	g.PUT("/packages/:id", a.UpdatePackage, RequirePermission(core.PermPlansWrite))
+	g.GET("/locations", a.ListLocations, RequirePermission(core.PermLocationsRead))
+	g.POST("/locations", a.CreateLocation, RequirePermission(core.PermLocationsWrite))
+	g.PUT("/locations/:id", a.UpdateLocation, RequirePermission(core.PermLocationsWrite))
+	g.DELETE("/locations/:id", a.DeleteLocation, RequirePermission(core.PermLocationsWrite))
+	g.GET("/devices", a.ListDevices, RequirePermission(core.PermDevicesManage))
+	g.POST("/devices", a.CreateDevice, RequirePermission(core.PermDevicesManage))
+	g.PUT("/devices/:id", a.UpdateDevice, RequirePermission(core.PermDevicesManage))
+	g.POST("/devices/:id/rotate-key", a.RotateDeviceKey, RequirePermission(core.PermDevicesManage))
+	g.DELETE("/devices/:id", a.RevokeDevice, RequirePermission(core.PermDevicesManage))
+	g.POST("/plans", a.CreatePlan, RequirePermission(core.PermPlansWrite))
+	g.PUT("/plans/:id", a.UpdatePlan, RequirePermission(core.PermPlansWrite))
+	g.DELETE("/plans/:id", a.DeletePlan, RequirePermission(core.PermPlansWrite))
+	g.POST("/plans/:id/reassign", a.ReassignPlan, RequirePermission(core.PermPlansWrite))
+	g.GET("/audit", a.ListAudit, RequirePermission(core.PermAuditRead))
+	g.GET("/stats", a.GetStats, RequirePermission(core.PermStatsRead))
+	g.GET("/charts", a.GetCharts, RequirePermission(core.PermStatsRead))
+	g.GET("/events/stream", a.StreamWashEvents, RequirePermission(core.PermMembersRead))
+	g.GET("/overrides", a.ListOverrides, RequirePermission(core.PermStatsRead))
+	g.GET("/permissions", a.ListPermissions, RequirePermission(core.PermRolesManage))
+	g.GET("/roles", a.ListRoles, RequirePermission(core.PermRolesManage))
+	g.POST("/roles", a.CreateRole, RequirePermission(core.PermRolesManage))
+	g.PUT("/roles/:id", a.UpdateRole, RequirePermission(core.PermRolesManage))
+	g.PUT("/roles/:id/require-2fa", a.SetRoleRequire2FA, RequirePermission(core.PermRolesManage))
+	g.DELETE("/roles/:id", a.DeleteRole, RequirePermission(core.PermRolesManage))
 }
 
 type AdminAuditItem struct {
@@ -102,22 +95,26 @@ func (a *AdminAPIService) audit(c echo.Context, action, entityType, entityID str
 	if a.db == nil {
 		return
 	}
-	v := c.Get("adminUserID")
-	adminID, ok := v.(int64)
-	if !ok || adminID == 0 {
+	p, ok := principalFrom(c)
+	if !ok {
 		return
 	}
+	writeAudit(a.db, p.UserID, action, entityType, entityID, detail)
+}
 
+// writeAudit appends to the admin audit log. actorID is whoever performed
+// the action; for self-service security changes that's the user themselves.
+func writeAudit(db *sqlx.DB, actorID int64, action, entityType, entityID string, detail any) {
 	b, err := json.Marshal(detail)
 	if err != nil {
 		b = []byte(`{}`)
 	}
 
-	q := a.db.Rebind(`
+	q := db.Rebind(`
 		INSERT INTO admin_audit_log (id, admin_user_id, action, entity_type, entity_id, detail)
 		VALUES (?, ?, ?, ?, ?, ?::jsonb)
 	`)
-	_, _ = a.db.Exec(q, uuid.NewString(), adminID, action, entityType, entityID, string(b))
+	_, _ = db.Exec(q, uuid.NewString(), actorID, action, entityType, entityID, string(b))
 }
 
 func (a *AdminAPIService) ListAudit(c echo.Context) error {
@@ -158,18 +155,27 @@ func (a *AdminAPIService) ListAudit(c echo.Context) error {
 }
 
 type AdminMember struct {
-	ID          int64  `json:"id" db:"id"`
-	Username    string `json:"username" db:"username"`
-	Email       string `json:"email" db:"email"`
-	FirstName   string `json:"firstName" db:"first_name"`
-	LastName    string `json:"lastName" db:"last_name"`
-	AvatarURL   string `json:"avatarUrl" db:"avatar_url"`
-	CreatedAt   string `json:"createdAt" db:"created_at"`
-	PlanID      string `json:"planId" db:"plan_id"`
-	PlanName    string `json:"planName" db:"plan_name"`
+	ID            int64  `json:"id" db:"id"`
+	Username      string `json:"username" db:"username"`
+	Email         string `json:"email" db:"email"`
+	EmailVerified bool   `json:"emailVerified" db:"email_verified"`
+	TwoFactor     bool   `json:"twoFactorEnabled" db:"two_factor_enabled"`
+	FailedLogins  int    `json:"failedLogins" db:"failed_logins"`
+	LockedUntil   string `json:"lockedUntil" db:"locked_until"`
+	FirstName     string `json:"firstName" db:"first_name"`
+	LastName      string `json:"lastName" db:"last_name"`
+	AvatarURL     string `json:"avatarUrl" db:"avatar_url"`
+	Role          string `json:"role" db:"role"`
+	CreatedAt     string `json:"createdAt" db:"created_at"`
+	PlanID        string `json:"planId" db:"plan_id"`
+	PlanName      string `json:"planName" db:"plan_name"`
+	// SubStatus is the status of the member's first active subscription,
+	// else of a paused or past_due one, else "none".
 	SubStatus   string `json:"subStatus" db:"sub_status"`
 	NextBilling string `json:"nextBillingDate" db:"next_billing_date"`
-	Washes      int    `json:"washCount" db:"wash_count"`
+	// ActiveSubs counts the member's active subscriptions, one per car.
+	ActiveSubs int `json:"activeSubscriptions" db:"active_subscriptions"`
+	Washes     int `json:"washCount" db:"wash_count"`
 }
 
 func (a *AdminAPIService) ListMembers(c echo.Context) error {
@@ -180,41 +186,55 @@ func (a *AdminAPIService) ListMembers(c echo.Context) error {
 			days = v
 		}
 	}
-	locationID := strings.TrimSpace(c.QueryParam("locationId"))
-	if locationID == "all" {
-		locationID = ""
+	locationID, locs, ok := scopedLocations(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
 	}
+	locIn, locArgs := locationInClause("location_id", locs)
 
 	filterJoin := ""
 	filterWhere := ""
 	args := []any{}
 
 	// Filter members by "has scan at location in last N days"
-	if locationID != "" {
+	if locs != nil {
 		filterJoin = `
 		JOIN (
 			SELECT DISTINCT user_id
 			FROM wash_events
-			WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')
-			  AND location_id = ?
+			WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')` + locIn + `
 		) lu ON lu.user_id = u.id
 		`
-		args = append(args, days, locationID)
+		args = append(args, days)
+		args = append(args, locArgs...)
 	}
 
 	q := a.db.Rebind(`
 		SELECT
-			u.id, u.username, u.email, u.first_name, u.last_name, u.avatar_url,
+			u.id, u.username, u.email, u.first_name, u.last_name, u.avatar_url, u.role,
+			(u.email_verified_at IS NOT NULL) as email_verified,
+			EXISTS (SELECT 1 FROM user_totp t WHERE t.user_id = u.id AND t.enabled_at IS NOT NULL) as two_factor_enabled,
+			COALESCE(lt.failures,0) as failed_logins,
+			COALESCE(CASE WHEN lt.locked_until > NOW() THEN lt.locked_until::text END,'') as locked_until,
 			COALESCE(u.created_at::text,'') as created_at,
 			COALESCE(s.plan_id,'') as plan_id,
 			COALESCE(p.name,'') as plan_name,
 			COALESCE(s.status,'none') as sub_status,
 			COALESCE(s.next_billing_date,'') as next_billing_date,
+			COALESCE(s.active_count,0) as active_subscriptions,
 			COALESCE(w.cnt,0) as wash_count
 		FROM users u
 		` + filterJoin + `
-		LEFT JOIN subscriptions s ON s.user_id = u.id AND s.status = 'active'
+		LEFT JOIN LATERAL (
+			SELECT plan_id, status, next_billing_date,
+			       COUNT(*) FILTER (WHERE status = 'active') OVER () AS active_count
+			FROM subscriptions
+			WHERE user_id = u.id AND status <> 'canceled'
+			ORDER BY status = 'active' DESC, next_billing_date
+			LIMIT 1
+		) s ON TRUE
 		LEFT JOIN plans p ON p.id = s.plan_id
+		LEFT JOIN login_throttle lt ON lt.scope = 'user' AND lt.key = u.id::text
 		LEFT JOIN (
 			SELECT user_id, COUNT(*) cnt
 			FROM wash_events
@@ -271,19 +291,47 @@ func (a *AdminAPIService) DeleteUser(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]any{"ok": true})
 }
 
+func (a *AdminAPIService) UnlockUser(c echo.Context) error {
+	idStr := c.Param("id")
+	uid, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || uid <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+	cleared, err := NewLoginThrottle(a.db).Unlock(uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if cleared {
+		a.audit(c, "user.unlock", "user", idStr, map[string]any{})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"ok": true, "cleared": cleared})
+}
+
 type AdminPlan struct {
-	ID           string `json:"id" db:"id"`
-	Name         string `json:"name" db:"name"`
-	PriceCents   int    `json:"priceCents" db:"price_cents"`
-	FeaturesJSON string `json:"featuresJson" db:"features_json"`
+	ID                 string                `json:"id" db:"id"`
+	Name               string                `json:"name" db:"name"`
+	PriceCents         int                   `json:"priceCents" db:"price_cents"`
+	ExtraCarPriceCents *int                  `json:"extraCarPriceCents" db:"extra_car_price_cents"`
+	FeaturesJSON       string                `json:"featuresJson" db:"features_json"`
+	EntitlementsJSON   string                `json:"-" db:"entitlements_json"`
+	Entitlements       core.PlanEntitlements `json:"entitlements" db:"-"`
+	PackageIDs         []string              `json:"packageIds" db:"-"`
 }
 
 func (a *AdminAPIService) ListPlans(c echo.Context) error {
-	q := a.db.Rebind(`SELECT id, name, price_cents, features_json FROM plans ORDER BY price_cents ASC`)
+	q := a.db.Rebind(`SELECT id, name, price_cents, extra_car_price_cents, features_json, entitlements_json FROM plans ORDER BY price_cents ASC`)
 	var plans []AdminPlan
 	if err := a.db.Select(&plans, q); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	included, err := loadPlanPackages(a.db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	for i := range plans {
+		plans[i].Entitlements = parseEntitlements(plans[i].EntitlementsJSON)
+		plans[i].PackageIDs = append([]string{}, included[plans[i].ID]...)
+	}
 	return c.JSON(http.StatusOK, map[string]any{"plans": plans})
 }
 
@@ -292,6 +340,44 @@ type planReq struct {
 	Name         string `json:"name"`
 	PriceCents   int    `json:"priceCents"`
 	FeaturesJSON string `json:"featuresJson"`
+	// Entitlements left out of an update keep their current value.
+	Entitlements *core.PlanEntitlements `json:"entitlements"`
+	// ExtraCarPriceCents is the family price for each additional car a
+	// member puts on the plan. Left out of an update it keeps its current
+	// value; null turns family pricing off.
+	ExtraCarPriceCents optionalInt `json:"extraCarPriceCents"`
+	// PackageIDs are the wash packages the plan includes. Left out of an
+	// update they keep their current value.
+	PackageIDs *[]string `json:"packageIds"`
+}
+
+// optionalInt tells a missing JSON field apart from an explicit null.
+type optionalInt struct {
+	Set   bool
+	Value *int
+}
+
+func (o *optionalInt) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	return json.Unmarshal(b, &o.Value)
+}
+
+// entitlementsJSON validates the requested rules, including that every
+// location exists, and encodes them for the plans table.
+func (a *AdminAPIService) entitlementsJSON(e core.PlanEntitlements) (string, error) {
+	if err := e.Validate(); err != nil {
+		return "", err
+	}
+	for _, id := range e.LocationIDs {
+		if !a.locationExists(id) {
+			return "", errors.New("unknown location in entitlements: " + id)
+		}
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
 }
 
 func (a *AdminAPIService) CreatePlan(c echo.Context) error {
@@ -307,15 +393,38 @@ func (a *AdminAPIService) CreatePlan(c echo.Context) error {
 	if req.PriceCents < 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "priceCents must be >= 0"})
 	}
+	if v := req.ExtraCarPriceCents.Value; v != nil && *v < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "extraCarPriceCents must be >= 0"})
+	}
 	if req.FeaturesJSON == "" {
 		req.FeaturesJSON = "[]"
 	}
+	var ent core.PlanEntitlements
+	if req.Entitlements != nil {
+		ent = *req.Entitlements
+	}
+	entJSON, err := a.entitlementsJSON(ent)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
-	q := a.db.Rebind(`INSERT INTO plans (id, name, price_cents, features_json) VALUES (?, ?, ?, ?)`)
-	if _, err := a.db.Exec(q, req.ID, req.Name, req.PriceCents, req.FeaturesJSON); err != nil {
+	packageIDs := []string{}
+	if req.PackageIDs != nil {
+		packageIDs = *req.PackageIDs
+	}
+	if err := a.packagesExist(packageIDs); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	q := a.db.Rebind(`INSERT INTO plans (id, name, price_cents, extra_car_price_cents, features_json, entitlements_json) VALUES (?, ?, ?, ?, ?, ?)`)
+	if _, err := a.db.Exec(q, req.ID, req.Name, req.PriceCents, req.ExtraCarPriceCents.Value, req.FeaturesJSON, entJSON); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := a.setPlanPackages(req.ID, packageIDs); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
-	a.audit(c, "plan.create", "plan", req.ID, map[string]any{"name": req.Name, "priceCents": req.PriceCents})
+	a.audit(c, "plan.create", "plan", req.ID, map[string]any{"name": req.Name, "priceCents": req.PriceCents,
+		"extraCarPriceCents": req.ExtraCarPriceCents.Value, "entitlements": ent, "packageIds": packageIDs})
 	return c.JSON(http.StatusOK, map[string]any{"ok": true})
 }
 
@@ -336,15 +445,48 @@ func (a *AdminAPIService) UpdatePlan(c echo.Context) error {
 	if req.PriceCents < 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "priceCents must be >= 0"})
 	}
+	if v := req.ExtraCarPriceCents.Value; v != nil && *v < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "extraCarPriceCents must be >= 0"})
+	}
 	if req.FeaturesJSON == "" {
 		req.FeaturesJSON = "[]"
 	}
+	detail := map[string]any{"name": req.Name, "priceCents": req.PriceCents}
+	if req.ExtraCarPriceCents.Set {
+		detail["extraCarPriceCents"] = req.ExtraCarPriceCents.Value
+	}
+	entJSON := ""
+	if req.Entitlements != nil {
+		var err error
+		if entJSON, err = a.entitlementsJSON(*req.Entitlements); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		detail["entitlements"] = *req.Entitlements
+	}
+	if req.PackageIDs != nil {
+		if err := a.packagesExist(*req.PackageIDs); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		detail["packageIds"] = *req.PackageIDs
+	}
 
-	q := a.db.Rebind(`UPDATE plans SET name = ?, price_cents = ?, features_json = ? WHERE id = ?`)
-	if _, err := a.db.Exec(q, req.Name, req.PriceCents, req.FeaturesJSON, planID); err != nil {
+	q := a.db.Rebind(`
+		UPDATE plans
+		SET name = ?, price_cents = ?, features_json = ?,
+		    entitlements_json = COALESCE(NULLIF(?, ''), entitlements_json),
+		    extra_car_price_cents = CASE WHEN ? THEN ?::int ELSE extra_car_price_cents END
+		WHERE id = ?
+	`)
+	if _, err := a.db.Exec(q, req.Name, req.PriceCents, req.FeaturesJSON, entJSON,
+		req.ExtraCarPriceCents.Set, req.ExtraCarPriceCents.Value, planID); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
-	a.audit(c, "plan.update", "plan", planID, map[string]any{"name": req.Name, "priceCents": req.PriceCents})
+	if req.PackageIDs != nil {
+		if err := a.setPlanPackages(planID, *req.PackageIDs); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	a.audit(c, "plan.update", "plan", planID, detail)
 	return c.JSON(http.StatusOK, map[string]any{"ok": true})
 }
 
@@ -376,6 +518,20 @@ type AdminStats struct {
 	AverageUsageRate  float64 `json:"averageUsageRate"`
 	MonthlyProjection float64 `json:"monthlyProjection"`
 	Days              int     `json:"days"`
+
+	// Subscriptions on hold or on their way out, and why members left
+	// during the window.
+	PausedCount        int           `json:"pausedCount"`
+	PastDueCount       int           `json:"pastDueCount"`
+	PendingCancelCount int           `json:"pendingCancelCount"`
+	CanceledInWindow   int           `json:"canceledInWindow"`
+	ChurnReasons       []churnReason `json:"churnReasons"`
+}
+
+type churnReason struct {
+	Code        string `json:"code" db:"code"`
+	Description string `json:"description" db:"-"`
+	Count       int    `json:"count" db:"cnt"`
 }
 
 func (a *AdminAPIService) GetStats(c echo.Context) error {
@@ -386,16 +542,17 @@ func (a *AdminAPIService) GetStats(c echo.Context) error {
 		}
 	}
 
-	locationID := strings.TrimSpace(c.QueryParam("locationId"))
-	if locationID == "all" {
-		locationID = ""
+	_, locs, ok := scopedLocations(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
 	}
+	locIn, locArgs := locationInClause("location_id", locs)
 
 	// Active members:
 	// - No filter: all active subscriptions
 	// - Filter: active subs whose users scanned at this location in window
 	var active int
-	if locationID == "" {
+	if locs == nil {
 		q1 := a.db.Rebind(`SELECT COUNT(1) FROM subscriptions WHERE status = 'active'`)
 		if err := a.db.Get(&active, q1); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -405,22 +562,21 @@ func (a *AdminAPIService) GetStats(c echo.Context) error {
 			WITH loc_users AS (
 				SELECT DISTINCT user_id
 				FROM wash_events
-				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')
-				  AND location_id = ?
+				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')` + locIn + `
 			)
 			SELECT COUNT(1)
 			FROM subscriptions s
 			WHERE s.status = 'active'
 			  AND s.user_id IN (SELECT user_id FROM loc_users)
 		`)
-		if err := a.db.Get(&active, q1, days, locationID); err != nil {
+		if err := a.db.Get(&active, q1, append([]any{days}, locArgs...)...); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 	}
 
 	// Scans in window, filtered by location if provided
 	var scans int
-	if locationID == "" {
+	if locs == nil {
 		q2 := a.db.Rebind(`SELECT COUNT(1) FROM wash_events WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')`)
 		if err := a.db.Get(&scans, q2, days); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -429,10 +585,9 @@ func (a *AdminAPIService) GetStats(c echo.Context) error {
 		q2 := a.db.Rebind(`
 			SELECT COUNT(1)
 			FROM wash_events
-			WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')
-			  AND location_id = ?
+			WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')` + locIn + `
 		`)
-		if err := a.db.Get(&scans, q2, days, locationID); err != nil {
+		if err := a.db.Get(&scans, q2, append([]any{days}, locArgs...)...); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 	}
@@ -444,12 +599,13 @@ func (a *AdminAPIService) GetStats(c echo.Context) error {
 
 	// Monthly projection: sum plan price for active subs (filtered users if location specified)
 	var cents int
-	if locationID == "" {
+	if locs == nil {
 		q3 := a.db.Rebind(`
-			SELECT COALESCE(SUM(p.price_cents), 0)
+			SELECT COALESCE(SUM(COALESCE(s.price_cents, p.price_cents)), 0)
 			FROM subscriptions s
 			JOIN plans p ON p.id = s.plan_id
 			WHERE s.status = 'active'
+			  AND NOT s.cancel_at_period_end
 		`)
 		if err := a.db.Get(&cents, q3); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -459,16 +615,16 @@ func (a *AdminAPIService) GetStats(c echo.Context) error {
 			WITH loc_users AS (
 				SELECT DISTINCT user_id
 				FROM wash_events
-				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')
-				  AND location_id = ?
+				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')` + locIn + `
 			)
-			SELECT COALESCE(SUM(p.price_cents), 0)
+			SELECT COALESCE(SUM(COALESCE(s.price_cents, p.price_cents)), 0)
 			FROM subscriptions s
 			JOIN plans p ON p.id = s.plan_id
 			WHERE s.status = 'active'
+			  AND NOT s.cancel_at_period_end
 			  AND s.user_id IN (SELECT user_id FROM loc_users)
 		`)
-		if err := a.db.Get(&cents, q3, days, locationID); err != nil {
+		if err := a.db.Get(&cents, q3, append([]any{days}, locArgs...)...); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 	}
@@ -480,9 +636,79 @@ func (a *AdminAPIService) GetStats(c echo.Context) error {
 		MonthlyProjection: float64(cents) / 100.0,
 		Days:              days,
 	}
+	if err := a.subscriptionLifecycle(&out, days, locs); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, out)
 }
 
+// subscriptionLifecycle fills the paused, past-due and cancellation counts,
+// scoped like the rest of the stats to members who washed at locs.
+func (a *AdminAPIService) subscriptionLifecycle(out *AdminStats, days int, locs []string) error {
+	scope, args := "", []any{days}
+	if locs != nil {
+		locIn, locArgs := locationInClause("location_id", locs)
+		scope = `
+			AND s.user_id IN (
+				SELECT DISTINCT user_id
+				FROM wash_events
+				WHERE scanned_at >= NOW() - (?::int * INTERVAL '1 day')` + locIn + `
+			)`
+		args = append(append(args, days), locArgs...)
+	}
+
+	var counts struct {
+		Paused        int `db:"paused"`
+		PastDue       int `db:"past_due"`
+		PendingCancel int `db:"pending_cancel"`
+		Canceled      int `db:"canceled"`
+	}
+	q := a.db.Rebind(`
+		SELECT COUNT(*) FILTER (WHERE s.status = 'paused') AS paused,
+		       COUNT(*) FILTER (WHERE s.status = 'past_due') AS past_due,
+		       COUNT(*) FILTER (WHERE s.status = 'active' AND s.cancel_at_period_end) AS pending_cancel,
+		       COUNT(*) FILTER (WHERE s.status = 'canceled' AND s.canceled_at >= NOW() - (?::int * INTERVAL '1 day')) AS canceled
+		FROM subscriptions s
+		WHERE TRUE` + scope + `
+	`)
+	if err := a.db.Get(&counts, q, args...); err != nil {
+		return err
+	}
+	out.PausedCount = counts.Paused
+	out.PastDueCount = counts.PastDue
+	out.PendingCancelCount = counts.PendingCancel
+	out.CanceledInWindow = counts.Canceled
+
+	out.ChurnReasons = []churnReason{}
+	q = a.db.Rebind(`
+		SELECT COALESCE(s.cancel_reason, '') AS code, COUNT(*) AS cnt
+		FROM subscriptions s
+		WHERE s.status = 'canceled'
+		  AND s.canceled_at >= NOW() - (?::int * INTERVAL '1 day')` + scope + `
+		GROUP BY 1
+		ORDER BY cnt DESC
+	`)
+	if err := a.db.Select(&out.ChurnReasons, q, args...); err != nil {
+		return err
+	}
+	for i, r := range out.ChurnReasons {
+		switch r.Code {
+		case "":
+			out.ChurnReasons[i].Description = "Not given"
+		case core.CancelReasonPaymentFailed:
+			out.ChurnReasons[i].Description = "Payment failed"
+		default:
+			out.ChurnReasons[i].Description = r.Code
+			for _, rc := range core.CancelReasons {
+				if rc.Code == r.Code {
+					out.ChurnReasons[i].Description = rc.Description
+				}
+			}
+		}
+	}
+	return nil
+}
+
 type reassignReq struct {
 	ToPlanID string `json:"toPlanId"`
 }
@@ -515,14 +741,29 @@ func (a *AdminAPIService) ReassignPlan(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid toPlanId"})
 	}
 
-	// Move ACTIVE subscriptions from fromPlan -> toPlan
-	q := a.db.Rebind(`UPDATE subscriptions SET plan_id = ? WHERE plan_id = ? AND status = 'active'`)
-	res, err := a.db.Exec(q, toPlan, fromPlan)
+	// Move ACTIVE subscriptions from fromPlan -> toPlan, each starting a new
+	// period on its timeline.
+	tx, err := a.db.Beginx()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	defer tx.Rollback()
+	var ids []string
+	q := tx.Rebind(`UPDATE subscriptions SET plan_id = ? WHERE plan_id = ? AND status = 'active' RETURNING id`)
+	if err := tx.Select(&ids, q, toPlan, fromPlan); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	for _, id := range ids {
+		if err := startSubscriptionPeriod(tx, id, today, core.PeriodReassigned); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 
-	moved, _ := res.RowsAffected()
+	moved := len(ids)
 
 	a.audit(c, "plan.reassign", "plan", fromPlan, map[string]any{
 		"toPlanId": toPlan,
@@ -545,9 +786,11 @@ type locationReq struct {
 }
 
 func (a *AdminAPIService) ListLocations(c echo.Context) error {
-	q := a.db.Rebind(`SELECT id, name, COALESCE(address,'') AS address FROM locations ORDER BY name ASC`)
+	p, _ := principalFrom(c)
+	locIn, locArgs := locationInClause("id", p.locationScope())
+	q := a.db.Rebind(`SELECT id, name, COALESCE(address,'') AS address FROM locations WHERE 1 = 1` + locIn + ` ORDER BY name ASC`)
 	var locs []AdminLocation
-	if err := a.db.Select(&locs, q); err != nil {
+	if err := a.db.Select(&locs, q, locArgs...); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, map[string]any{"locations": locs})
@@ -614,6 +857,11 @@ func (a *AdminAPIService) DeleteLocation(c echo.Context) error {
 		a.audit(c, "location.delete_blocked", "location", locID, map[string]any{"events": cnt})
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "cannot delete location: wash events exist"})
 	}
+	q2 := a.db.Rebind(`SELECT COUNT(1) FROM scanner_devices WHERE location_id = ?`)
+	if err := a.db.Get(&cnt, q2, locID); err == nil && cnt > 0 {
+		a.audit(c, "location.delete_blocked", "location", locID, map[string]any{"devices": cnt})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "cannot delete location: scanner devices exist"})
+	}
 
 	q := a.db.Rebind(`DELETE FROM locations WHERE id = ?`)
 	if _, err := a.db.Exec(q, locID); err != nil {
@@ -643,6 +891,95 @@ type AdminCharts struct {
 	HeatmapMorning   []int    `json:"heatmapMorning"`
 	HeatmapAfternoon []int    `json:"heatmapAfternoon"`
 	HeatmapEvening   []int    `json:"heatmapEvening"`
+
+	// Scans in the window per attendant, device and lane (top 10 each).
+	AttendantLabels []string `json:"attendantLabels"`
+	AttendantScans  []int    `json:"attendantScans"`
+	DeviceLabels    []string `json:"deviceLabels"`
+	DeviceScans     []int    `json:"deviceScans"`
+	LaneLabels      []string `json:"laneLabels"`
+	LaneScans       []int    `json:"laneScans"`
+
+	// Washes in the window per package at each location: PackageMix[i][j] is
+	// PackageLocations[i] by PackageLabels[j]. UpchargeRevenue is what members
+	// paid on top of their plans.
+	PackageLabels    []string `json:"packageLabels"`
+	PackageLocations []string `json:"packageLocations"`
+	PackageMix       [][]int  `json:"packageMix"`
+	UpchargeRevenue  float64  `json:"upchargeRevenue"`
+}
+
+// scanBreakdown counts scans in the last days grouped by label, which is an
+// expression over wash_events e and whatever join adds.
+func (a *AdminAPIService) scanBreakdown(label, join, notNull string, days int, locs []string) ([]string, []int) {
+	locIn, locArgs := locationInClause("e.location_id", locs)
+	q := a.db.Rebind(`
+		SELECT ` + label + ` AS label, COUNT(*) AS cnt
+		FROM wash_events e
+		` + join + `
+		WHERE e.scanned_at >= NOW() - (?::int * interval '1 day')
+		  AND ` + notNull + ` IS NOT NULL` + locIn + `
+		GROUP BY 1
+		ORDER BY cnt DESC, label ASC
+		LIMIT 10
+	`)
+	var rows []struct {
+		Label string `db:"label"`
+		Cnt   int    `db:"cnt"`
+	}
+	_ = a.db.Select(&rows, q, append([]any{days}, locArgs...)...)
+
+	labels, counts := []string{}, []int{}
+	for _, r := range rows {
+		labels = append(labels, r.Label)
+		counts = append(counts, r.Cnt)
+	}
+	return labels, counts
+}
+
+// packageMix counts washes in the last days by location and package, and
+// sums their upcharges.
+func (a *AdminAPIService) packageMix(days int, locs []string) (packages, locations []string, mix [][]int, upchargeCents int) {
+	locIn, locArgs := locationInClause("e.location_id", locs)
+	q := a.db.Rebind(`
+		SELECT COALESCE(l.name, e.location_id, '') AS location, p.name AS package,
+		       COUNT(*) AS cnt, COALESCE(SUM(e.upcharge_cents), 0) AS upcharge
+		FROM wash_events e
+		JOIN wash_packages p ON p.id = e.package_id
+		LEFT JOIN locations l ON l.id = e.location_id
+		WHERE e.scanned_at >= NOW() - (?::int * interval '1 day')` + locIn + `
+		GROUP BY 1, 2, p.sort_order
+		ORDER BY p.sort_order ASC, 1 ASC
+	`)
+	var rows []struct {
+		Location string `db:"location"`
+		Package  string `db:"package"`
+		Cnt      int    `db:"cnt"`
+		Upcharge int    `db:"upcharge"`
+	}
+	_ = a.db.Select(&rows, q, append([]any{days}, locArgs...)...)
+
+	pkgIdx, locIdx := map[string]int{}, map[string]int{}
+	packages, locations = []string{}, []string{}
+	for _, r := range rows {
+		if _, ok := pkgIdx[r.Package]; !ok {
+			pkgIdx[r.Package] = len(packages)
+			packages = append(packages, r.Package)
+		}
+		if _, ok := locIdx[r.Location]; !ok {
+			locIdx[r.Location] = len(locations)
+			locations = append(locations, r.Location)
+		}
+	}
+	mix = make([][]int, len(locations))
+	for i := range mix {
+		mix[i] = make([]int, len(packages))
+	}
+	for _, r := range rows {
+		mix[locIdx[r.Location]][pkgIdx[r.Package]] = r.Cnt
+		upchargeCents += r.Upcharge
+	}
+	return packages, locations, mix, upchargeCents
 }
 
 func (a *AdminAPIService) GetCharts(c echo.Context) error {
@@ -653,14 +990,15 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 		}
 	}
 
-	locationID := strings.TrimSpace(c.QueryParam("locationId"))
-	if locationID == "all" {
-		locationID = ""
+	_, locs, ok := scopedLocations(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
 	}
+	locIn, locArgs := locationInClause("location_id", locs)
 
 	// Active members: if filtering, scope to users who scanned at that location in window.
 	var active int
-	if locationID == "" {
+	if locs == nil {
 		q := a.db.Rebind(`SELECT COUNT(1) FROM subscriptions WHERE status = 'active'`)
 		if err := a.db.Get(&active, q); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -670,27 +1008,27 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 			WITH loc_users AS (
 				SELECT DISTINCT user_id
 				FROM wash_events
-				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')
-				  AND location_id = ?
+				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')` + locIn + `
 			)
 			SELECT COUNT(1)
 			FROM subscriptions s
 			WHERE s.status = 'active'
 			  AND s.user_id IN (SELECT user_id FROM loc_users)
 		`)
-		if err := a.db.Get(&active, q, days, locationID); err != nil {
+		if err := a.db.Get(&active, q, append([]any{days}, locArgs...)...); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 	}
 
 	// Monthly projection cents (filtered users if location specified)
 	var cents int
-	if locationID == "" {
+	if locs == nil {
 		q := a.db.Rebind(`
-			SELECT COALESCE(SUM(p.price_cents), 0)
+			SELECT COALESCE(SUM(COALESCE(s.price_cents, p.price_cents)), 0)
 			FROM subscriptions s
 			JOIN plans p ON p.id = s.plan_id
 			WHERE s.status = 'active'
+			  AND NOT s.cancel_at_period_end
 		`)
 		if err := a.db.Get(&cents, q); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -700,16 +1038,16 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 			WITH loc_users AS (
 				SELECT DISTINCT user_id
 				FROM wash_events
-				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')
-				  AND location_id = ?
+				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')` + locIn + `
 			)
-			SELECT COALESCE(SUM(p.price_cents), 0)
+			SELECT COALESCE(SUM(COALESCE(s.price_cents, p.price_cents)), 0)
 			FROM subscriptions s
 			JOIN plans p ON p.id = s.plan_id
 			WHERE s.status = 'active'
+			  AND NOT s.cancel_at_period_end
 			  AND s.user_id IN (SELECT user_id FROM loc_users)
 		`)
-		if err := a.db.Get(&cents, q, days, locationID); err != nil {
+		if err := a.db.Get(&cents, q, append([]any{days}, locArgs...)...); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 	}
@@ -721,13 +1059,6 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 		Users int    `db:"users"`
 	}
 
-	whereLoc := ""
-	argsLoc := make([]any, 0, 1)
-	if locationID != "" {
-		whereLoc = " AND location_id = ?"
-		argsLoc = append(argsLoc, locationID)
-	}
-
 	qDailySQL := `
 		WITH days AS (
 			SELECT generate_series(
@@ -742,7 +1073,7 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 				COUNT(*) AS scans,
 				COUNT(DISTINCT user_id) AS users
 			FROM wash_events
-			WHERE scanned_at >= NOW() - (?::int * interval '1 day')` + whereLoc + `
+			WHERE scanned_at >= NOW() - (?::int * interval '1 day')` + locIn + `
 			GROUP BY 1
 		)
 		SELECT
@@ -755,7 +1086,7 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 	`
 	qDaily := a.db.Rebind(qDailySQL)
 
-	argsDaily := append([]any{days, days}, argsLoc...)
+	argsDaily := append([]any{days, days}, locArgs...)
 	var rows []dayRow
 	if err := a.db.Select(&rows, qDaily, argsDaily...); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -790,7 +1121,7 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 		Cnt  int    `db:"cnt"`
 	}
 	var mix []mixRow
-	if locationID == "" {
+	if locs == nil {
 		qMix := a.db.Rebind(`
 			SELECT p.name, COUNT(*) AS cnt
 			FROM subscriptions s
@@ -805,8 +1136,7 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 			WITH loc_users AS (
 				SELECT DISTINCT user_id
 				FROM wash_events
-				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')
-				  AND location_id = ?
+				WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')` + locIn + `
 			)
 			SELECT p.name, COUNT(*) AS cnt
 			FROM subscriptions s
@@ -816,7 +1146,7 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 			GROUP BY p.name
 			ORDER BY cnt DESC
 		`)
-		_ = a.db.Select(&mix, qMix, days, locationID)
+		_ = a.db.Select(&mix, qMix, append([]any{days}, locArgs...)...)
 	}
 
 	planLabels := []string{}
@@ -838,13 +1168,6 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 		Cnt     int    `db:"cnt"`
 	}
 
-	heatWhere := ""
-	heatArgs := make([]any, 0, 1)
-	if locationID != "" {
-		heatWhere = " AND location_id = ?"
-		heatArgs = append(heatArgs, locationID)
-	}
-
 	qHeatSQL := `
 		SELECT
 			(EXTRACT(DOW FROM scanned_at)::int) AS dow,
@@ -855,13 +1178,13 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 			END AS segment,
 			COUNT(*) AS cnt
 		FROM wash_events
-		WHERE scanned_at >= NOW() - (7 * interval '1 day')` + heatWhere + `
+		WHERE scanned_at >= NOW() - (7 * interval '1 day')` + locIn + `
 		GROUP BY 1, 2
 	`
 	qHeat := a.db.Rebind(qHeatSQL)
 
 	var heat []heatRow
-	_ = a.db.Select(&heat, qHeat, heatArgs...)
+	_ = a.db.Select(&heat, qHeat, locArgs...)
 
 	// Postgres DOW: 0=Sun..6=Sat. We want 0=Mon..6=Sun
 	mapDow := func(pgDow int) int {
@@ -886,6 +1209,14 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 		}
 	}
 
+	attLabels, attScans := a.scanBreakdown(
+		`COALESCE(NULLIF(TRIM(COALESCE(u.first_name,'') || ' ' || COALESCE(u.last_name,'')), ''), u.username, 'User #' || e.scanned_by::text)`,
+		`LEFT JOIN users u ON u.id = e.scanned_by`, "e.scanned_by", days, locs)
+	devLabels, devScans := a.scanBreakdown(`COALESCE(d.name, e.device_id)`,
+		`LEFT JOIN scanner_devices d ON d.id = e.device_id`, "e.device_id", days, locs)
+	laneLabels, laneScans := a.scanBreakdown(`e.lane`, "", "e.lane", days, locs)
+	pkgLabels, pkgLocations, pkgMix, upcharge := a.packageMix(days, locs)
+
 	out := AdminCharts{
 		Days: days,
 
@@ -905,6 +1236,18 @@ func (a *AdminAPIService) GetCharts(c echo.Context) error {
 		HeatmapMorning:   morn,
 		HeatmapAfternoon: aft,
 		HeatmapEvening:   eve,
+
+		AttendantLabels: attLabels,
+		AttendantScans:  attScans,
+		DeviceLabels:    devLabels,
+		DeviceScans:     devScans,
+		LaneLabels:      laneLabels,
+		LaneScans:       laneScans,
+
+		PackageLabels:    pkgLabels,
+		PackageLocations: pkgLocations,
+		PackageMix:       pkgMix,
+		UpchargeRevenue:  float64(upcharge) / 100.0,
 	}
 
 	return c.JSON(http.StatusOK, out)
@@ -917,6 +1260,13 @@ type AdminWashEvent struct {
 	Result     string `json:"result" db:"result"`
 	Reason     string `json:"reason" db:"reason"`
 	RawQR      string `json:"rawQr" db:"raw_qr"`
+	Offline    bool   `json:"offline" db:"offline"`
+	// ScannedBy is the attendant's user ID, or 0 for device scans.
+	ScannedBy     int64  `json:"scannedBy" db:"scanned_by"`
+	ScannedByName string `json:"scannedByName" db:"scanned_by_name"`
+	DeviceID      string `json:"deviceId" db:"device_id"`
+	DeviceName    string `json:"deviceName" db:"device_name"`
+	Lane          string `json:"lane" db:"lane"`
 }
 
 func (a *AdminAPIService) GetMemberDetail(c echo.Context) error {
@@ -933,16 +1283,29 @@ func (a *AdminAPIService) GetMemberDetail(c echo.Context) error {
 	// Member summary (matches ListMembers fields)
 	q := a.db.Rebind(`
 		SELECT
-			u.id, u.username, u.email, u.first_name, u.last_name, u.avatar_url,
+			u.id, u.username, u.email, u.first_name, u.last_name, u.avatar_url, u.role,
+			(u.email_verified_at IS NOT NULL) as email_verified,
+			EXISTS (SELECT 1 FROM user_totp t WHERE t.user_id = u.id AND t.enabled_at IS NOT NULL) as two_factor_enabled,
+			COALESCE(lt.failures,0) as failed_logins,
+			COALESCE(CASE WHEN lt.locked_until > NOW() THEN lt.locked_until::text END,'') as locked_until,
 			COALESCE(u.created_at::text,'') as created_at,
 			COALESCE(s.plan_id,'') as plan_id,
 			COALESCE(p.name,'') as plan_name,
 			COALESCE(s.status,'none') as sub_status,
 			COALESCE(s.next_billing_date,'') as next_billing_date,
+			COALESCE(s.active_count,0) as active_subscriptions,
 			COALESCE(w.cnt,0) as wash_count
 		FROM users u
-		LEFT JOIN subscriptions s ON s.user_id = u.id AND s.status = 'active'
+		LEFT JOIN LATERAL (
+			SELECT plan_id, status, next_billing_date,
+			       COUNT(*) FILTER (WHERE status = 'active') OVER () AS active_count
+			FROM subscriptions
+			WHERE user_id = u.id AND status <> 'canceled'
+			ORDER BY status = 'active' DESC, next_billing_date
+			LIMIT 1
+		) s ON TRUE
 		LEFT JOIN plans p ON p.id = s.plan_id
+		LEFT JOIN login_throttle lt ON lt.scope = 'user' AND lt.key = u.id::text
 		LEFT JOIN (
 			SELECT user_id, COUNT(*) cnt
 			FROM wash_events
@@ -956,6 +1319,18 @@ func (a *AdminAPIService) GetMemberDetail(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "member not found"})
 	}
 
+	// Location-scoped staff only see members who washed at one of their sites,
+	// and only the events from those sites.
+	p, _ := principalFrom(c)
+	locIn, locArgs := locationInClause("e.location_id", p.locationScope())
+	if locIn != "" {
+		var seen int
+		qSeen := a.db.Rebind(`SELECT COUNT(1) FROM wash_events e WHERE e.user_id = ?` + locIn)
+		if err := a.db.Get(&seen, qSeen, append([]any{uid}, locArgs...)...); err != nil || seen == 0 {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "member not found"})
+		}
+	}
+
 	// Recent events
 	q2 := a.db.Rebind(`
 		SELECT
@@ -964,18 +1339,32 @@ func (a *AdminAPIService) GetMemberDetail(c echo.Context) error {
 			COALESCE(l.name,'') as location_name,
 			COALESCE(e.result,'') as result,
 			COALESCE(e.reason,'') as reason,
-			COALESCE(e.raw_qr,'') as raw_qr
+			COALESCE(e.raw_qr,'') as raw_qr,
+			e.offline,
+			COALESCE(e.scanned_by, 0) as scanned_by,
+			COALESCE(NULLIF(TRIM(COALESCE(sb.first_name,'') || ' ' || COALESCE(sb.last_name,'')), ''), sb.username, '') as scanned_by_name,
+			COALESCE(e.device_id,'') as device_id,
+			COALESCE(d.name,'') as device_name,
+			COALESCE(e.lane,'') as lane
 		FROM wash_events e
 		LEFT JOIN locations l ON l.id = e.location_id
-		WHERE e.user_id = ?
+		LEFT JOIN users sb ON sb.id = e.scanned_by
+		LEFT JOIN scanner_devices d ON d.id = e.device_id
+		WHERE e.user_id = ?` + locIn + `
 		ORDER BY e.scanned_at DESC
 		LIMIT 25
 	`)
 	var events []AdminWashEvent
-	_ = a.db.Select(&events, q2, uid)
+	_ = a.db.Select(&events, q2, append([]any{uid}, locArgs...)...)
+
+	timeline, err := subscriptionTimeline(a.db, int(uid))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 
 	return c.JSON(http.StatusOK, map[string]any{
-		"member":     m,
-		"washEvents": events,
+		"member":               m,
+		"washEvents":           events,
+		"subscriptionTimeline": timeline,
 	})
 }
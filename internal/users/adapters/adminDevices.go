@@ -0,0 +1,165 @@
+package adapters
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AdminDevice struct {
+	ID              string  `json:"id" db:"id"`
+	Name            string  `json:"name" db:"name"`
+	LocationID      string  `json:"locationId" db:"location_id"`
+	LocationName    string  `json:"locationName" db:"location_name"`
+	Lane            string  `json:"lane" db:"lane"`
+	KeyPrefix       string  `json:"keyPrefix" db:"key_prefix"`
+	CertFingerprint string  `json:"certFingerprint" db:"cert_fingerprint"`
+	CreatedAt       string  `json:"createdAt" db:"created_at"`
+	LastSeenAt      *string `json:"lastSeenAt" db:"last_seen_at"`
+	LastSeenIP      string  `json:"lastSeenIp" db:"last_seen_ip"`
+	RevokedAt       *string `json:"revokedAt" db:"revoked_at"`
+}
+
+type deviceReq struct {
+	Name            string `json:"name"`
+	LocationID      string `json:"locationId"`
+	Lane            string `json:"lane"`
+	CertFingerprint string `json:"certFingerprint"`
+}
+
+func (r *deviceReq) normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.LocationID = strings.TrimSpace(r.LocationID)
+	r.Lane = strings.TrimSpace(r.Lane)
+	r.CertFingerprint = normalizeFingerprint(r.CertFingerprint)
+}
+
+func (a *AdminAPIService) locationExists(id string) bool {
+	var exists int
+	return a.db.Get(&exists, a.db.Rebind(`SELECT 1 FROM locations WHERE id = ? LIMIT 1`), id) == nil
+}
+
+func (a *AdminAPIService) ListDevices(c echo.Context) error {
+	q := a.db.Rebind(`
+		SELECT d.id, d.name, d.location_id, COALESCE(l.name,'') AS location_name, d.lane,
+		       d.key_prefix, COALESCE(d.cert_fingerprint,'') AS cert_fingerprint,
+		       d.created_at::text AS created_at, d.last_seen_at::text AS last_seen_at,
+		       COALESCE(d.last_seen_ip,'') AS last_seen_ip, d.revoked_at::text AS revoked_at
+		FROM scanner_devices d
+		LEFT JOIN locations l ON l.id = d.location_id
+		ORDER BY d.revoked_at IS NOT NULL, l.name ASC, d.name ASC
+	`)
+	items := []AdminDevice{}
+	if err := a.db.Select(&items, q); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"devices": items})
+}
+
+// CreateDevice registers a kiosk. The API key is only ever returned here and
+// from RotateDeviceKey.
+func (a *AdminAPIService) CreateDevice(c echo.Context) error {
+	var req deviceReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	req.normalize()
+	if req.Name == "" || req.LocationID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name and locationId are required"})
+	}
+	if !a.locationExists(req.LocationID) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown location"})
+	}
+
+	key, prefix, err := newDeviceKey()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not generate key"})
+	}
+	p, _ := principalFrom(c)
+	id := uuid.NewString()
+	q := a.db.Rebind(`
+		INSERT INTO scanner_devices (id, name, location_id, lane, api_key_hash, key_prefix, cert_fingerprint, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)
+	`)
+	if _, err := a.db.Exec(q, id, req.Name, req.LocationID, req.Lane, hashAccountToken(key), prefix, req.CertFingerprint, p.UserID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	a.audit(c, "device.create", "device", id, map[string]any{
+		"name":            req.Name,
+		"locationId":      req.LocationID,
+		"lane":            req.Lane,
+		"certFingerprint": req.CertFingerprint,
+	})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true, "id": id, "apiKey": key, "keyPrefix": prefix})
+}
+
+func (a *AdminAPIService) UpdateDevice(c echo.Context) error {
+	id := strings.TrimSpace(c.Param("id"))
+	var req deviceReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	req.normalize()
+	if req.Name == "" || req.LocationID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name and locationId are required"})
+	}
+	if !a.locationExists(req.LocationID) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown location"})
+	}
+
+	q := a.db.Rebind(`UPDATE scanner_devices SET name = ?, location_id = ?, lane = ?, cert_fingerprint = NULLIF(?, '') WHERE id = ?`)
+	res, err := a.db.Exec(q, req.Name, req.LocationID, req.Lane, req.CertFingerprint, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "device not found"})
+	}
+
+	a.audit(c, "device.update", "device", id, map[string]any{
+		"name":            req.Name,
+		"locationId":      req.LocationID,
+		"lane":            req.Lane,
+		"certFingerprint": req.CertFingerprint,
+	})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+// RotateDeviceKey replaces the key; the old one stops working immediately.
+func (a *AdminAPIService) RotateDeviceKey(c echo.Context) error {
+	id := strings.TrimSpace(c.Param("id"))
+	key, prefix, err := newDeviceKey()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not generate key"})
+	}
+	q := a.db.Rebind(`UPDATE scanner_devices SET api_key_hash = ?, key_prefix = ? WHERE id = ? AND revoked_at IS NULL`)
+	res, err := a.db.Exec(q, hashAccountToken(key), prefix, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "device not found"})
+	}
+
+	a.audit(c, "device.rotate_key", "device", id, map[string]any{"keyPrefix": prefix})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true, "apiKey": key, "keyPrefix": prefix})
+}
+
+// RevokeDevice disables the device. The row is kept so past scans still resolve.
+func (a *AdminAPIService) RevokeDevice(c echo.Context) error {
+	id := strings.TrimSpace(c.Param("id"))
+	q := a.db.Rebind(`UPDATE scanner_devices SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL`)
+	res, err := a.db.Exec(q, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "device not found"})
+	}
+
+	a.audit(c, "device.revoke", "device", id, map[string]any{})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
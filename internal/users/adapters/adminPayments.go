@@ -0,0 +1,94 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/labstack/echo/v4"
+)
+
+// ListPayments lists charges, newest first, optionally for one member
+// (?userId) or status (?status).
+func (a *AdminAPIService) ListPayments(c echo.Context) error {
+	where, args := []string{"1 = 1"}, []any{}
+	if s := strings.TrimSpace(c.QueryParam("userId")); s != "" {
+		uid, err := strconv.Atoi(s)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid userId"})
+		}
+		where, args = append(where, "user_id = ?"), append(args, uid)
+	}
+	if s := strings.TrimSpace(c.QueryParam("status")); s != "" {
+		where, args = append(where, "status = ?"), append(args, s)
+	}
+
+	payments := []PaymentRow{}
+	q := a.db.Rebind(paymentSelect + ` WHERE ` + strings.Join(where, " AND ") + ` ORDER BY created_at DESC LIMIT 500`)
+	if err := a.db.Select(&payments, q, args...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"payments": payments})
+}
+
+// RefundPayment gives back part or, when amountCents is left out, all of
+// what remains of a charge.
+func (a *AdminAPIService) RefundPayment(c echo.Context) error {
+	id := strings.TrimSpace(c.Param("id"))
+	var req struct {
+		AmountCents int    `json:"amountCents"`
+		Reason      string `json:"reason"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+
+	var p PaymentRow
+	if err := a.db.Get(&p, a.db.Rebind(paymentSelect+` WHERE id = ?`), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "payment not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if p.Status != core.ChargeSucceeded || p.ProviderChargeID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "only settled payments can be refunded"})
+	}
+	remaining := p.AmountCents - p.RefundedCents
+	if req.AmountCents == 0 {
+		req.AmountCents = remaining
+	}
+	if req.AmountCents < 0 || req.AmountCents > remaining {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "amountCents must be between 1 and " + strconv.Itoa(remaining)})
+	}
+
+	refund, err := a.payments.Refund(p.ProviderChargeID, req.AmountCents, "refund-"+p.ID+"-"+strconv.Itoa(p.RefundedCents))
+	if err != nil {
+		return c.JSON(paymentStatus(err), map[string]string{"error": err.Error()})
+	}
+	// The refund webhook carries the same total and may land first.
+	total := p.RefundedCents + refund.AmountCents
+	q := a.db.Rebind(`
+		UPDATE payments
+		SET refunded_cents = GREATEST(refunded_cents, ?),
+		    status = CASE WHEN GREATEST(refunded_cents, ?) >= amount_cents THEN 'refunded' ELSE status END,
+		    updated_at = NOW()
+		WHERE id = ?
+	`)
+	if _, err := a.db.Exec(q, total, total, p.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	a.audit(c, "payment.refund", "payment", p.ID, map[string]any{
+		"userId":      p.UserID,
+		"amountCents": refund.AmountCents,
+		"refundId":    refund.ID,
+		"reason":      strings.TrimSpace(req.Reason),
+	})
+
+	if err := a.db.Get(&p, a.db.Rebind(paymentSelect+` WHERE id = ?`), id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, p)
+}
@@ -0,0 +1,303 @@
+package adapters
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/labstack/echo/v4"
+)
+
+var roleIDRe = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+type AdminRole struct {
+	ID          string   `json:"id" db:"id"`
+	Name        string   `json:"name" db:"name"`
+	Description string   `json:"description" db:"description"`
+	BuiltIn     bool     `json:"builtIn" db:"built_in"`
+	Require2FA  bool     `json:"require2fa" db:"require_2fa"`
+	Users       int      `json:"userCount" db:"user_count"`
+	Permissions []string `json:"permissions" db:"-"`
+}
+
+type roleReq struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Require2FA  bool     `json:"require2fa"`
+}
+
+type setUserRoleReq struct {
+	Role string `json:"role"`
+}
+
+type setRequire2FAReq struct {
+	Required bool `json:"required"`
+}
+
+func (a *AdminAPIService) ListPermissions(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{"permissions": core.Permissions})
+}
+
+func (a *AdminAPIService) ListRoles(c echo.Context) error {
+	q := a.db.Rebind(`
+		SELECT r.id, r.name, r.description, r.built_in, r.require_2fa, COALESCE(u.cnt, 0) AS user_count
+		FROM roles r
+		LEFT JOIN (SELECT role, COUNT(*) cnt FROM users GROUP BY role) u ON u.role = r.id
+		ORDER BY r.built_in DESC, r.name ASC
+	`)
+	var roles []AdminRole
+	if err := a.db.Select(&roles, q); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	type permRow struct {
+		RoleID     string `db:"role_id"`
+		Permission string `db:"permission"`
+	}
+	var perms []permRow
+	if err := a.db.Select(&perms, `SELECT role_id, permission FROM role_permissions ORDER BY permission`); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	byRole := map[string][]string{}
+	for _, p := range perms {
+		byRole[p.RoleID] = append(byRole[p.RoleID], p.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = byRole[roles[i].ID]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"roles": roles})
+}
+
+// normalizePermissions trims, dedupes and validates against the catalog.
+func normalizePermissions(in []string) ([]string, string) {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, p := range in {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if !core.IsPermission(p) {
+			return nil, "unknown permission: " + p
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out, ""
+}
+
+func (a *AdminAPIService) CreateRole(c echo.Context) error {
+	var req roleReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	req.ID = strings.ToLower(strings.TrimSpace(req.ID))
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if !roleIDRe.MatchString(req.ID) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "id must be 2-32 lowercase letters, digits, - or _"})
+	}
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+	perms, msg := normalizePermissions(req.Permissions)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(tx.Rebind(`INSERT INTO roles (id, name, description, require_2fa) VALUES (?, ?, ?, ?)`), req.ID, req.Name, req.Description, req.Require2FA); err != nil {
+		if isUniqueViolation(err) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "role already exists"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	for _, p := range perms {
+		if _, err := tx.Exec(tx.Rebind(`INSERT INTO role_permissions (role_id, permission) VALUES (?, ?)`), req.ID, p); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	a.audit(c, "role.create", "role", req.ID, map[string]any{"name": req.Name, "permissions": perms, "require2fa": req.Require2FA})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+func (a *AdminAPIService) UpdateRole(c echo.Context) error {
+	roleID := strings.TrimSpace(c.Param("id"))
+	if roleID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing role id"})
+	}
+	// Guard: the admin role always keeps every permission so nobody can lock themselves out.
+	if roleID == core.RoleAdmin {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "the admin role cannot be edited"})
+	}
+
+	var req roleReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+	perms, msg := normalizePermissions(req.Permissions)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	var before []string
+	_ = a.db.Select(&before, a.db.Rebind(`SELECT permission FROM role_permissions WHERE role_id = ? ORDER BY permission`), roleID)
+
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(tx.Rebind(`UPDATE roles SET name = ?, description = ? WHERE id = ?`), req.Name, req.Description, roleID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "role not found"})
+	}
+	if _, err := tx.Exec(tx.Rebind(`DELETE FROM role_permissions WHERE role_id = ?`), roleID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	for _, p := range perms {
+		if _, err := tx.Exec(tx.Rebind(`INSERT INTO role_permissions (role_id, permission) VALUES (?, ?)`), roleID, p); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	a.audit(c, "role.update", "role", roleID, map[string]any{
+		"name":        req.Name,
+		"before":      before,
+		"permissions": perms,
+	})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+func (a *AdminAPIService) DeleteRole(c echo.Context) error {
+	roleID := strings.TrimSpace(c.Param("id"))
+	if roleID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing role id"})
+	}
+
+	var builtIn bool
+	if err := a.db.Get(&builtIn, a.db.Rebind(`SELECT built_in FROM roles WHERE id = ?`), roleID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "role not found"})
+	}
+	if builtIn {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "built-in roles cannot be deleted"})
+	}
+
+	// Guard: don't orphan users
+	var cnt int
+	if err := a.db.Get(&cnt, a.db.Rebind(`SELECT COUNT(1) FROM users WHERE role = ?`), roleID); err == nil && cnt > 0 {
+		a.audit(c, "role.delete_blocked", "role", roleID, map[string]any{"users": cnt})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "role is assigned to users; move them first"})
+	}
+
+	if _, err := a.db.Exec(a.db.Rebind(`DELETE FROM roles WHERE id = ?`), roleID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	a.audit(c, "role.delete", "role", roleID, map[string]any{})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+func (a *AdminAPIService) SetUserRole(c echo.Context) error {
+	idStr := c.Param("id")
+	uid, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || uid <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+	if p, _ := principalFrom(c); p.UserID == uid {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "cannot change your own role"})
+	}
+
+	var req setUserRoleReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	role := strings.TrimSpace(req.Role)
+
+	var exists int
+	if err := a.db.Get(&exists, a.db.Rebind(`SELECT 1 FROM roles WHERE id = ? LIMIT 1`), role); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid role"})
+	}
+
+	var before string
+	if err := a.db.Get(&before, a.db.Rebind(`SELECT role FROM users WHERE id = ? LIMIT 1`), uid); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	if before == role {
+		return c.JSON(http.StatusOK, map[string]any{"ok": true})
+	}
+
+	if _, err := a.db.Exec(a.db.Rebind(`UPDATE users SET role = ? WHERE id = ?`), role, uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	a.audit(c, "user.role_change", "user", idStr, map[string]any{"from": before, "to": role})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+// SetRoleRequire2FA is separate from UpdateRole so it also works on the
+// admin role, whose permissions are locked.
+func (a *AdminAPIService) SetRoleRequire2FA(c echo.Context) error {
+	roleID := strings.TrimSpace(c.Param("id"))
+	var req setRequire2FAReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+
+	res, err := a.db.Exec(a.db.Rebind(`UPDATE roles SET require_2fa = ? WHERE id = ?`), req.Required, roleID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "role not found"})
+	}
+	a.audit(c, "role.require_2fa", "role", roleID, map[string]any{"required": req.Required})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+// ResetUserTwoFactor clears a user's authenticator and recovery codes, e.g.
+// after a lost phone. If their role requires 2FA they must enroll again.
+func (a *AdminAPIService) ResetUserTwoFactor(c echo.Context) error {
+	idStr := c.Param("id")
+	uid, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || uid <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+	tf := NewTwoFactor(a.db)
+	if !tf.Enabled(uid) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": errTwoFactorNotEnabled.Error()})
+	}
+	if err := tf.Disable(uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	a.audit(c, "2fa.reset", "user", idStr, map[string]any{})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
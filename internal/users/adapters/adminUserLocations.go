@@ -0,0 +1,89 @@
+package adapters
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type setUserLocationsReq struct {
+	LocationIDs []string `json:"locationIds"`
+}
+
+func (a *AdminAPIService) GetUserLocations(c echo.Context) error {
+	uid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || uid <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+	var exists int
+	if err := a.db.Get(&exists, a.db.Rebind(`SELECT 1 FROM users WHERE id = ? LIMIT 1`), uid); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"locationIds": loadUserLocations(a.db, uid)})
+}
+
+// SetUserLocations replaces the user's location assignments.
+func (a *AdminAPIService) SetUserLocations(c echo.Context) error {
+	idStr := c.Param("id")
+	uid, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || uid <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	var req setUserLocationsReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	seen := map[string]bool{}
+	locs := []string{}
+	for _, id := range req.LocationIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		locs = append(locs, id)
+	}
+	sort.Strings(locs)
+
+	var exists int
+	if err := a.db.Get(&exists, a.db.Rebind(`SELECT 1 FROM users WHERE id = ? LIMIT 1`), uid); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	if len(locs) > 0 {
+		locIn, locArgs := locationInClause("id", locs)
+		var found int
+		if err := a.db.Get(&found, a.db.Rebind(`SELECT COUNT(1) FROM locations WHERE 1 = 1`+locIn), locArgs...); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if found != len(locs) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown location"})
+		}
+	}
+
+	before := loadUserLocations(a.db, uid)
+
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(tx.Rebind(`DELETE FROM user_locations WHERE user_id = ?`), uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	for _, id := range locs {
+		if _, err := tx.Exec(tx.Rebind(`INSERT INTO user_locations (user_id, location_id) VALUES (?, ?)`), uid, id); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	a.audit(c, "user.locations_change", "user", idStr, map[string]any{"from": before, "to": locs})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true, "locationIds": locs})
+}
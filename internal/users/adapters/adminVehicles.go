@@ -0,0 +1,81 @@
+package adapters
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdminVehicle struct {
+	CarID     string `json:"carId" db:"car_id"`
+	UserID    int64  `json:"userId" db:"user_id"`
+	UserName  string `json:"userName" db:"user_name"`
+	Email     string `json:"email" db:"email"`
+	Nickname  string `json:"nickname" db:"nickname"`
+	Year      *int   `json:"year" db:"year"`
+	Make      string `json:"make" db:"make"`
+	Model     string `json:"model" db:"model"`
+	Color     string `json:"color" db:"color"`
+	Plate     string `json:"plate" db:"plate"`
+	VIN       string `json:"vin" db:"vin"`
+	CreatedAt string `json:"createdAt" db:"created_at"`
+	// AccountWide is set when the owner still has a subscription from before
+	// per-car plans, which lets this car through at the scanner.
+	AccountWide bool   `json:"accountWide" db:"account_wide"`
+	LastWashAt  string `json:"lastWashAt" db:"last_wash_at"`
+}
+
+// ListUnsubscribedVehicles lists registered cars without their own active
+// subscription. Location-scoped staff only see cars of members who washed at
+// one of their sites in the last ?days, as in ListMembers.
+func (a *AdminAPIService) ListUnsubscribedVehicles(c echo.Context) error {
+	days := 30
+	if ds := strings.TrimSpace(c.QueryParam("days")); ds != "" {
+		if v, err := strconv.Atoi(ds); err == nil && v > 0 && v <= 365 {
+			days = v
+		}
+	}
+	locationID, locs, ok := scopedLocations(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
+	}
+
+	filterJoin := ""
+	args := []any{}
+	if locs != nil {
+		locIn, locArgs := locationInClause("location_id", locs)
+		filterJoin = `
+		JOIN (
+			SELECT DISTINCT user_id
+			FROM wash_events
+			WHERE scanned_at >= NOW() - (? * INTERVAL '1 day')` + locIn + `
+		) lu ON lu.user_id = c.user_id
+		`
+		args = append(append(args, days), locArgs...)
+	}
+
+	q := a.db.Rebind(`
+		SELECT c.id AS car_id, c.user_id,
+		       COALESCE(NULLIF(TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), ''), u.username, '') AS user_name,
+		       COALESCE(u.email, '') AS email,
+		       c.nickname, c.year, c.make, c.model, c.color, c.plate, c.vin,
+		       COALESCE(c.created_at::text, '') AS created_at,
+		       EXISTS (SELECT 1 FROM subscriptions s
+		               WHERE s.user_id = c.user_id AND s.car_id IS NULL AND s.status = 'active') AS account_wide,
+		       COALESCE((SELECT MAX(w.scanned_at)::text FROM wash_events w
+		                 WHERE w.car_id = c.id AND w.result IN ('allowed', 'allowed_override')), '') AS last_wash_at
+		FROM cars c
+		JOIN users u ON u.id = c.user_id
+		` + filterJoin + `
+		WHERE NOT EXISTS (SELECT 1 FROM subscriptions s WHERE s.car_id = c.id AND s.status <> 'canceled')
+		ORDER BY c.created_at DESC
+		LIMIT 500
+	`)
+	vehicles := []AdminVehicle{}
+	if err := a.db.Select(&vehicles, q, args...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"vehicles": vehicles, "days": days, "locationId": locationID})
+}
@@ -0,0 +1,201 @@
+package adapters
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// sessionCookieName is the one cookie every sign-in path sets.
+const sessionCookieName = "session_token"
+
+// Older builds set these; they're still read so existing logins keep working.
+var legacySessionCookies = []string{"token", "Auth"}
+
+const principalContextKey = "principal"
+
+// Principal is the authenticated caller, resolved once per request.
+type Principal struct {
+	UserID      int64
+	Role        string
+	SessionID   int64
+	Token       string
+	Permissions map[core.Permission]bool
+	// Locations the caller is assigned to; only consulted without locations.all.
+	Locations []string
+	// TwoFactorPending is set when the role requires 2FA and the user hasn't
+	// enrolled; every permission is withheld until they do.
+	TwoFactorPending bool
+	// ImpersonatorID is the admin acting as this user, or 0.
+	ImpersonatorID int64
+}
+
+func (p Principal) Can(perm core.Permission) bool {
+	return !p.TwoFactorPending && p.Permissions[perm]
+}
+
+type AuthMiddleware struct {
+	db *sqlx.DB
+}
+
+func NewAuthMiddleware(db *sqlx.DB) *AuthMiddleware {
+	return &AuthMiddleware{db: db}
+}
+
+// Resolve looks up the request's session, if any, and stores the Principal
+// on the context. It never rejects a request; use RequireAuth/RequirePermission for that.
+func (a *AuthMiddleware) Resolve(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, fromCookie := sessionTokenFromRequest(c)
+		if token == "" {
+			return next(c)
+		}
+
+		sess, ok := lookupSession(a.db, token)
+		if !ok && fromCookie {
+			// An impersonation that ran out drops the admin back into their own session.
+			token, sess, ok = restoreImpersonatorSession(c, a.db)
+		}
+		if !ok {
+			return next(c)
+		}
+
+		c.Set(principalContextKey, Principal{
+			UserID:      sess.UserID,
+			Role:        sess.Role,
+			SessionID:   sess.ID,
+			Token:       token,
+			Permissions: loadRolePermissions(a.db, sess.Role),
+			Locations:   loadUserLocations(a.db, sess.UserID),
+
+			TwoFactorPending: twoFactorPending(a.db, sess.UserID, sess.Role),
+			ImpersonatorID:   sess.ImpersonatorID,
+		})
+		if fromCookie && sess.Renewed {
+			setSessionCookie(c, token)
+		}
+
+		err := next(c)
+		if sess.ImpersonatorID > 0 {
+			auditImpersonatedRequest(c, a.db, sess)
+		}
+		return err
+	}
+}
+
+func principalFrom(c echo.Context) (Principal, bool) {
+	p, ok := c.Get(principalContextKey).(Principal)
+	return p, ok && p.UserID > 0
+}
+
+// RequireAuth rejects API requests without a valid session.
+func RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := principalFrom(c); !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		}
+		return next(c)
+	}
+}
+
+// RequirePermission rejects API requests whose role lacks perm.
+func RequirePermission(perm core.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p, ok := principalFrom(c)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			}
+			if p.TwoFactorPending && p.Permissions[perm] {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "two-factor enrollment required", "permission": string(perm)})
+			}
+			if !p.Can(perm) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden", "permission": string(perm)})
+			}
+			return next(c)
+		}
+	}
+}
+
+// DenyImpersonation blocks account-security routes for impersonation sessions.
+func DenyImpersonation(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if p, ok := principalFrom(c); ok && p.ImpersonatorID > 0 {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "not allowed while impersonating"})
+		}
+		return next(c)
+	}
+}
+
+func loadRolePermissions(db *sqlx.DB, role string) map[core.Permission]bool {
+	perms := map[core.Permission]bool{}
+	var rows []string
+	q := db.Rebind(`SELECT permission FROM role_permissions WHERE role_id = ?`)
+	if err := db.Select(&rows, q, role); err != nil {
+		return perms
+	}
+	for _, r := range rows {
+		perms[core.Permission(r)] = true
+	}
+	return perms
+}
+
+// sessionTokenFromRequest accepts Authorization: Bearer <token>,
+// X-Session-Token, or the session cookie. fromCookie reports whether the
+// token came from the current session cookie.
+func sessionTokenFromRequest(c echo.Context) (token string, fromCookie bool) {
+	auth := c.Request().Header.Get("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") {
+		if t := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")); t != "" {
+			return t, false
+		}
+	}
+	if t := strings.TrimSpace(c.Request().Header.Get("X-Session-Token")); t != "" {
+		return t, false
+	}
+	if ck, err := c.Cookie(sessionCookieName); err == nil && ck.Value != "" {
+		return ck.Value, true
+	}
+	for _, name := range legacySessionCookies {
+		if ck, err := c.Cookie(name); err == nil && ck.Value != "" {
+			return ck.Value, false
+		}
+	}
+	return "", false
+}
+
+func setSessionCookie(c echo.Context, token string) {
+	ttl := sessionTTL()
+	c.SetCookie(&http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(ttl),
+		MaxAge:   int(ttl / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   os.Getenv("ENV") == "production",
+	})
+}
+
+// clearSessionCookies expires the session cookie and any legacy ones.
+func clearSessionCookies(c echo.Context) {
+	secure := os.Getenv("ENV") == "production"
+	for _, name := range append([]string{sessionCookieName}, legacySessionCookies...) {
+		c.SetCookie(&http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			Expires:  time.Unix(0, 0),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   secure,
+		})
+	}
+}
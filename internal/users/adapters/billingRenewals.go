@@ -0,0 +1,411 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/edlingao/hexago/internal/users/ports"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	billingCurrency           = "usd"
+	defaultBillingGracePeriod = 7 * 24 * time.Hour
+	defaultBillingRetry       = 24 * time.Hour
+	renewalBatchSize          = 500
+	// billingLockNamespace is the first key of the per-member advisory lock
+	// held while a member subscribes or changes plan.
+	billingLockNamespace = 7302
+)
+
+// billingGracePeriod is how long a past_due subscription is retried before
+// it is canceled (BILLING_GRACE_DAYS).
+func billingGracePeriod() time.Duration {
+	if s := strings.TrimSpace(os.Getenv("BILLING_GRACE_DAYS")); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour
+		}
+	}
+	return defaultBillingGracePeriod
+}
+
+// billingRetryInterval is the wait between charges of a past_due
+// subscription (BILLING_RETRY_HOURS).
+func billingRetryInterval() time.Duration {
+	if s := strings.TrimSpace(os.Getenv("BILLING_RETRY_HOURS")); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return time.Duration(n) * time.Hour
+		}
+	}
+	return defaultBillingRetry
+}
+
+// subscriptionEvent is one row of subscription_history.
+type subscriptionEvent struct {
+	SubscriptionID string
+	UserID         int
+	Event          string
+	FromStatus     string
+	ToStatus       string
+	AmountCents    *int
+	ChargeID       string
+	Detail         string
+	PeriodStart    string
+	PeriodEnd      string
+}
+
+func recordSubscriptionEvent(db sqlx.Ext, ev subscriptionEvent) error {
+	q := db.Rebind(`
+		INSERT INTO subscription_history (subscription_id, user_id, event, from_status, to_status,
+		                                  amount_cents, charge_id, detail, period_start, period_end)
+		VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), NULLIF(?, ''))
+	`)
+	_, err := db.Exec(q, ev.SubscriptionID, ev.UserID, ev.Event, ev.FromStatus, ev.ToStatus,
+		ev.AmountCents, ev.ChargeID, ev.Detail, ev.PeriodStart, ev.PeriodEnd)
+	return err
+}
+
+// nextBillingDateAfter moves a billing date on by whole months until it is
+// past today. Periods missed while nothing renewed are not billed.
+func nextBillingDateAfter(date string, now time.Time) string {
+	today := now.UTC().Format("2006-01-02")
+	next, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		next, _ = time.Parse("2006-01-02", today)
+	}
+	for next.Format("2006-01-02") <= today {
+		next = next.AddDate(0, 1, 0)
+	}
+	return next.Format("2006-01-02")
+}
+
+// RenewalEngine charges subscriptions whose next_billing_date has come and
+// walks failed ones through past_due to canceled.
+type RenewalEngine struct {
+	db       *sqlx.DB
+	payments ports.TakingPayments
+}
+
+func NewRenewalEngine(db *sqlx.DB, payments ports.TakingPayments) *RenewalEngine {
+	return &RenewalEngine{db: db, payments: payments}
+}
+
+// Start runs the engine every interval until the process exits.
+func (e *RenewalEngine) Start(every time.Duration) {
+	run := func() {
+		renewed, failed, canceled, err := e.RunOnce(time.Now())
+		if err != nil {
+			log.Println("billing:", err)
+		}
+		if renewed+failed+canceled > 0 {
+			log.Printf("billing: renewed %d, failed %d, canceled %d", renewed, failed, canceled)
+		}
+	}
+
+	go func() {
+		run()
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for range t.C {
+			run()
+		}
+	}()
+}
+
+// RunOnce renews everything due at now, after resuming paused subscriptions
+// whose hold is over. Each subscription is locked while it is charged, so
+// several instances can run the engine at once.
+func (e *RenewalEngine) RunOnce(now time.Time) (renewed, failed, canceled int, err error) {
+	if n, err := e.resumePaused(now); err != nil {
+		log.Println("billing: resuming paused subscriptions:", err)
+	} else if n > 0 {
+		log.Printf("billing: resumed %d paused subscriptions", n)
+	}
+
+	var ids []string
+	q := e.db.Rebind(`
+		SELECT id FROM subscriptions
+		WHERE (status = 'active' AND next_billing_date <= ?)
+		   OR (status = 'past_due' AND (last_renewal_attempt_at IS NULL OR last_renewal_attempt_at <= ?))
+		ORDER BY next_billing_date ASC
+		LIMIT ?
+	`)
+	if err := e.db.Select(&ids, q, now.UTC().Format("2006-01-02"), now.Add(-billingRetryInterval()), renewalBatchSize); err != nil {
+		return 0, 0, 0, err
+	}
+	for _, id := range ids {
+		to, err := e.renew(id, now)
+		if err != nil {
+			log.Printf("billing: subscription %s: %v", id, err)
+			continue
+		}
+		switch to {
+		case core.SubscriptionActive:
+			renewed++
+		case core.SubscriptionPastDue:
+			failed++
+		case core.SubscriptionCanceled:
+			canceled++
+		}
+	}
+	return renewed, failed, canceled, nil
+}
+
+// resumePaused reactivates subscriptions whose paused_until has come. Their
+// next_billing_date was pushed out when they paused, so nothing is charged.
+// The resumes, their new periods and their history save together.
+func (e *RenewalEngine) resumePaused(now time.Time) (int, error) {
+	today := now.UTC().Format("2006-01-02")
+	tx, err := e.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var resumed []struct {
+		ID     string `db:"id"`
+		UserID int    `db:"user_id"`
+	}
+	q := tx.Rebind(`
+		UPDATE subscriptions
+		SET status = 'active', paused_at = NULL, paused_until = NULL
+		WHERE status = 'paused' AND paused_until <= ?
+		RETURNING id, user_id
+	`)
+	if err := tx.Select(&resumed, q, today); err != nil {
+		return 0, err
+	}
+	for _, r := range resumed {
+		if err := startSubscriptionPeriod(tx, r.ID, today, core.PeriodResumed); err != nil {
+			return 0, err
+		}
+		if err := recordSubscriptionEvent(tx, subscriptionEvent{
+			SubscriptionID: r.ID,
+			UserID:         r.UserID,
+			Event:          "resumed",
+			FromStatus:     core.SubscriptionPaused,
+			ToStatus:       core.SubscriptionActive,
+			Detail:         "pause ended",
+		}); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(resumed), nil
+}
+
+// renew charges one subscription if it is still due and returns the status
+// it moved to, or "" when there was nothing to do.
+func (e *RenewalEngine) renew(id string, now time.Time) (string, error) {
+	tx, err := e.db.Beginx()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var sub struct {
+		ID              string       `db:"id"`
+		UserID          int          `db:"user_id"`
+		Status          string       `db:"status"`
+		NextBillingDate string       `db:"next_billing_date"`
+		PastDueSince    sql.NullTime `db:"past_due_since"`
+		LastAttemptAt   sql.NullTime `db:"last_renewal_attempt_at"`
+		Attempts        int          `db:"renewal_attempts"`
+		PlanID          string       `db:"plan_id"`
+		PendingPlanID   string       `db:"pending_plan_id"`
+		AmountCents     int          `db:"amount_cents"`
+		CreditCents     int          `db:"credit_cents"`
+		PlanName        string       `db:"plan_name"`
+		CancelAtEnd     bool         `db:"cancel_at_period_end"`
+	}
+	// A change scheduled for the period end takes effect with this renewal,
+	// so the new period is billed at the new plan's price.
+	q := tx.Rebind(`
+		SELECT s.id, s.user_id, s.status, s.next_billing_date, s.past_due_since, s.last_renewal_attempt_at,
+		       s.renewal_attempts, s.plan_id, COALESCE(s.pending_plan_id, '') AS pending_plan_id,
+		       CASE WHEN s.pending_plan_id IS NOT NULL THEN COALESCE(s.pending_price_cents, pp.price_cents)
+		            ELSE COALESCE(s.price_cents, p.price_cents) END AS amount_cents,
+		       s.credit_cents, COALESCE(pp.name, p.name) AS plan_name, s.cancel_at_period_end
+		FROM subscriptions s
+		JOIN plans p ON p.id = s.plan_id
+		LEFT JOIN plans pp ON pp.id = s.pending_plan_id
+		WHERE s.id = ? AND s.status IN ('active', 'past_due')
+		FOR NO KEY UPDATE OF s SKIP LOCKED
+	`)
+	if err := tx.Get(&sub, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Locked by another instance, or no longer billable.
+			return "", nil
+		}
+		return "", err
+	}
+	switch sub.Status {
+	case core.SubscriptionActive:
+		if sub.NextBillingDate > now.UTC().Format("2006-01-02") {
+			return "", nil
+		}
+	case core.SubscriptionPastDue:
+		if sub.LastAttemptAt.Valid && now.Sub(sub.LastAttemptAt.Time) < billingRetryInterval() {
+			return "", nil
+		}
+	}
+
+	// The member asked to stop at the end of the period that just ran out.
+	if sub.Status == core.SubscriptionActive && sub.CancelAtEnd {
+		q = tx.Rebind(`
+			UPDATE subscriptions
+			SET status = 'canceled', canceled_at = ?, cancel_at_period_end = FALSE,
+			    pending_plan_id = NULL, pending_price_cents = NULL
+			WHERE id = ?
+		`)
+		if _, err := tx.Exec(q, now, sub.ID); err != nil {
+			return "", err
+		}
+		if err := endSubscriptionPeriod(tx, sub.ID, sub.NextBillingDate, core.PeriodCanceled); err != nil {
+			return "", err
+		}
+		if err := recordSubscriptionEvent(tx, subscriptionEvent{
+			SubscriptionID: sub.ID,
+			UserID:         sub.UserID,
+			Event:          "canceled",
+			FromStatus:     sub.Status,
+			ToStatus:       core.SubscriptionCanceled,
+			Detail:         "at period end",
+			PeriodEnd:      sub.NextBillingDate,
+		}); err != nil {
+			return "", err
+		}
+		return core.SubscriptionCanceled, tx.Commit()
+	}
+
+	// Out of grace: cancel without charging again.
+	if sub.Status == core.SubscriptionPastDue && sub.PastDueSince.Valid && now.Sub(sub.PastDueSince.Time) >= billingGracePeriod() {
+		q = tx.Rebind(`UPDATE subscriptions SET status = 'canceled', canceled_at = ?, cancel_reason = ? WHERE id = ?`)
+		if _, err := tx.Exec(q, now, core.CancelReasonPaymentFailed, sub.ID); err != nil {
+			return "", err
+		}
+		// The last period paid for ended at the billing date that failed.
+		if err := endSubscriptionPeriod(tx, sub.ID, sub.NextBillingDate, core.PeriodCanceled); err != nil {
+			return "", err
+		}
+		if err := recordSubscriptionEvent(tx, subscriptionEvent{
+			SubscriptionID: sub.ID,
+			UserID:         sub.UserID,
+			Event:          "canceled",
+			FromStatus:     sub.Status,
+			ToStatus:       core.SubscriptionCanceled,
+			Detail:         fmt.Sprintf("No payment within the grace period after %d attempts", sub.Attempts),
+		}); err != nil {
+			return "", err
+		}
+		return core.SubscriptionCanceled, tx.Commit()
+	}
+
+	// Credit comes off first; with nothing left to pay (or a free plan) it
+	// renews without a charge. Retries of the same period use a new
+	// key per attempt; a crash before commit reuses the key, so the provider
+	// doesn't charge twice. The payment is recorded outside this transaction,
+	// hence NO KEY UPDATE above: its foreign key check must not wait on us.
+	credit := min(max(sub.CreditCents, 0), sub.AmountCents)
+	amount := sub.AmountCents - credit
+	var payment PaymentRow
+	var chargeErr error
+	if amount > 0 {
+		payment, chargeErr = chargeMember(e.db, e.payments, memberCharge{
+			UserID:         sub.UserID,
+			SubscriptionID: sub.ID,
+			AmountCents:    amount,
+			Description:    sub.PlanName + " renewal",
+			IdempotencyKey: fmt.Sprintf("renew-%s-%s-%d", sub.ID, sub.NextBillingDate, sub.Attempts),
+		})
+	}
+
+	if chargeErr != nil {
+		q = tx.Rebind(`
+			UPDATE subscriptions
+			SET status = 'past_due', past_due_since = COALESCE(past_due_since, ?),
+			    renewal_attempts = renewal_attempts + 1, last_renewal_attempt_at = ?
+			WHERE id = ?
+		`)
+		if _, err := tx.Exec(q, now, now, sub.ID); err != nil {
+			return "", err
+		}
+		if err := recordSubscriptionEvent(tx, subscriptionEvent{
+			SubscriptionID: sub.ID,
+			UserID:         sub.UserID,
+			Event:          "payment_failed",
+			FromStatus:     sub.Status,
+			ToStatus:       core.SubscriptionPastDue,
+			AmountCents:    &amount,
+			Detail:         chargeErr.Error(),
+			PeriodStart:    sub.NextBillingDate,
+		}); err != nil {
+			return "", err
+		}
+		return core.SubscriptionPastDue, tx.Commit()
+	}
+
+	next := nextBillingDateAfter(sub.NextBillingDate, now)
+	periodStart := sub.NextBillingDate
+	if t, err := time.Parse("2006-01-02", next); err == nil {
+		periodStart = t.AddDate(0, -1, 0).Format("2006-01-02")
+	}
+	q = tx.Rebind(`
+		UPDATE subscriptions
+		SET status = 'active', next_billing_date = ?, past_due_since = NULL,
+		    renewal_attempts = 0, last_renewal_attempt_at = ?,
+		    plan_id = COALESCE(pending_plan_id, plan_id),
+		    price_cents = CASE WHEN pending_plan_id IS NOT NULL THEN pending_price_cents ELSE price_cents END,
+		    pending_plan_id = NULL, pending_price_cents = NULL,
+		    credit_cents = credit_cents - ?
+		WHERE id = ?
+	`)
+	if _, err := tx.Exec(q, next, now, credit, sub.ID); err != nil {
+		return "", err
+	}
+	if sub.PendingPlanID != "" {
+		if err := startSubscriptionPeriod(tx, sub.ID, periodStart, core.PeriodScheduledChange); err != nil {
+			return "", err
+		}
+		if err := recordSubscriptionEvent(tx, subscriptionEvent{
+			SubscriptionID: sub.ID,
+			UserID:         sub.UserID,
+			Event:          "plan_changed",
+			FromStatus:     sub.Status,
+			ToStatus:       core.SubscriptionActive,
+			Detail:         sub.PlanID + " -> " + sub.PendingPlanID + " (scheduled)",
+			PeriodStart:    periodStart,
+			PeriodEnd:      next,
+		}); err != nil {
+			return "", err
+		}
+	}
+	detail := ""
+	if credit > 0 {
+		detail = fmt.Sprintf("%d cents of credit applied", credit)
+	}
+	if err := recordSubscriptionEvent(tx, subscriptionEvent{
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		Event:          "renewed",
+		FromStatus:     sub.Status,
+		ToStatus:       core.SubscriptionActive,
+		AmountCents:    &amount,
+		ChargeID:       payment.ProviderChargeID,
+		Detail:         detail,
+		PeriodStart:    periodStart,
+		PeriodEnd:      next,
+	}); err != nil {
+		return "", err
+	}
+	return core.SubscriptionActive, tx.Commit()
+}
@@ -31,7 +31,7 @@ func (s *UsersStore[Item]) Insert(item Item) error {
 
 func (s *UsersStore[Item]) Get(id, table string) (Item, error) {
 	var item Item
-	err := s.db.Get(&item, "SELECT * FROM "+table+" WHERE id = ?", id)
+	err := s.db.Get(&item, s.db.Rebind("SELECT * FROM "+table+" WHERE id = ?"), id)
 	if err != nil {
 		return item, err
 	}
@@ -50,19 +50,24 @@ func (s *UsersStore[Item]) GetAll(table string) []Item {
 }
 
 func (s *UsersStore[Item]) Delete(id, table string) error {
-	_, err := s.db.Exec("DELETE FROM "+table+" WHERE id = ?", id)
+	_, err := s.db.Exec(s.db.Rebind("DELETE FROM "+table+" WHERE id = ?"), id)
+	return err
+}
+
+func (s *UsersStore[Item]) UpdatePassword(id, hash string) error {
+	_, err := s.db.Exec(s.db.Rebind("UPDATE users SET password = ? WHERE id = ?"), hash, id)
 	return err
 }
 
 func (s *UsersStore[Item]) DeleteByField(field, value, table string) error {
-	_, err := s.db.Exec("DELETE FROM "+table+" WHERE "+field+" = ?", value)
+	_, err := s.db.Exec(s.db.Rebind("DELETE FROM "+table+" WHERE "+field+" = ?"), value)
 
 	return err
 }
 
 func (s *UsersStore[Item]) GetByField(field, value, table string) (Item, error) {
 	var item Item
-	err := s.db.Get(&item, "SELECT * FROM "+table+" WHERE "+field+" = ?", value)
+	err := s.db.Get(&item, s.db.Rebind("SELECT * FROM "+table+" WHERE "+field+" = ?"), value)
 
 	if err != nil {
 		return item, err
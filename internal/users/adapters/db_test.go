@@ -0,0 +1,115 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+var (
+	createUsersRe = regexp.MustCompile(`(?is)CREATE TABLE IF NOT EXISTS users \((.*?)\n\);`)
+	alterUsersRe  = regexp.MustCompile(`(?is)ALTER TABLE users\s+((?:ADD COLUMN[^;]*?,?\s*)+);`)
+	addColumnRe   = regexp.MustCompile(`(?i)ADD COLUMN(?: IF NOT EXISTS)? (\w+)`)
+)
+
+// migratedUserColumns lists the users columns left once every migration's
+// Up section has run.
+func migratedUserColumns(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("../../../db/migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	sort.Strings(files)
+	seen := map[string]bool{}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(b), "-- +goose Down")
+		if m := createUsersRe.FindStringSubmatch(up); m != nil {
+			for _, line := range strings.Split(m[1], "\n") {
+				if f := strings.Fields(line); len(f) > 0 {
+					seen[strings.ToLower(f[0])] = true
+				}
+			}
+		}
+		for _, m := range alterUsersRe.FindAllStringSubmatch(up, -1) {
+			for _, c := range addColumnRe.FindAllStringSubmatch(m[1], -1) {
+				seen[strings.ToLower(c[1])] = true
+			}
+		}
+	}
+	cols := make([]string, 0, len(seen))
+	for c := range seen {
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+// UsersStore reads users with SELECT *, so core.User needs a field for
+// every column or sqlx refuses the row.
+func TestUserHasEveryMigratedColumn(t *testing.T) {
+	fields := reflectx.NewMapperFunc("db", strings.ToLower).TypeMap(reflect.TypeOf(core.User{})).Names
+	cols := migratedUserColumns(t)
+	if len(cols) == 0 {
+		t.Fatal("found no users columns in the migrations")
+	}
+	for _, c := range cols {
+		if _, ok := fields[c]; !ok {
+			t.Errorf("users.%s has no field in core.User", c)
+		}
+	}
+}
+
+// TestUsersStoreLoadsUser loads a user from a migrated Postgres database.
+// It runs when DATABASE_URL points at one.
+func TestUsersStoreLoadsUser(t *testing.T) {
+	if os.Getenv("DATABASE_URL") == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &UsersStore[core.User]{db: db}
+	defer store.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	var id string
+	q := tx.Rebind(`INSERT INTO users (username, password) VALUES (?, 'x') RETURNING id`)
+	if err := tx.Get(&id, q, "db-test-"+t.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(tx.Rebind(`UPDATE users SET payment_customer_id = 'cus_test' WHERE id = ?`), id); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Delete(id, "users")
+
+	u, err := store.Get(id, "users")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if u.PaymentCustomerID == nil || *u.PaymentCustomerID != "cus_test" {
+		t.Errorf("PaymentCustomerID = %v, want cus_test", u.PaymentCustomerID)
+	}
+	if _, err := store.GetByField("username", "db-test-"+t.Name(), "users"); err != nil {
+		t.Errorf("GetByField: %v", err)
+	}
+}
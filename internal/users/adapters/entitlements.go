@@ -0,0 +1,71 @@
+package adapters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx"
+)
+
+func parseEntitlements(raw string) core.PlanEntitlements {
+	var e core.PlanEntitlements
+	if strings.TrimSpace(raw) != "" {
+		_ = json.Unmarshal([]byte(raw), &e)
+	}
+	return e
+}
+
+func loadPlanEntitlements(db *sqlx.DB, planID string) core.PlanEntitlements {
+	var raw string
+	_ = db.Get(&raw, db.Rebind(`SELECT entitlements_json FROM plans WHERE id = ? LIMIT 1`), planID)
+	return parseEntitlements(raw)
+}
+
+// billingPeriodStart is one month before the next billing date, but never
+// before the subscription started.
+func billingPeriodStart(startDate, nextBillingDate string, now time.Time) time.Time {
+	start := now.AddDate(0, -1, 0)
+	if next, err := time.Parse("2006-01-02", nextBillingDate); err == nil {
+		start = next.AddDate(0, -1, 0)
+	}
+	if s, err := time.Parse("2006-01-02", startDate); err == nil && s.After(start) {
+		start = s
+	}
+	return start
+}
+
+// loadWashUsage counts the member's washes (allowed or overridden) up to now,
+// only those of carID when the subscription covers a single car. Days and
+// weeks are UTC.
+func loadWashUsage(db sqlx.Ext, userID int, carID string, periodStart, now time.Time) (core.WashUsage, error) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := dayStart.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+
+	var row struct {
+		Today      int          `db:"today"`
+		ThisWeek   int          `db:"this_week"`
+		ThisPeriod int          `db:"this_period"`
+		LastWashAt sql.NullTime `db:"last_wash_at"`
+	}
+	q := db.Rebind(`
+		SELECT COUNT(*) FILTER (WHERE scanned_at >= ?) AS today,
+		       COUNT(*) FILTER (WHERE scanned_at >= ?) AS this_week,
+		       COUNT(*) FILTER (WHERE scanned_at >= ?) AS this_period,
+		       MAX(scanned_at) AS last_wash_at
+		FROM wash_events
+		WHERE user_id = ? AND result IN ('allowed', 'allowed_override') AND scanned_at <= ?
+		  AND (? = '' OR car_id = ?)
+	`)
+	if err := sqlx.Get(db, &row, q, dayStart, weekStart, periodStart, userID, now, carID, carID); err != nil {
+		return core.WashUsage{}, err
+	}
+	u := core.WashUsage{Today: row.Today, ThisWeek: row.ThisWeek, ThisPeriod: row.ThisPeriod}
+	if row.LastWashAt.Valid {
+		u.LastWashAt = &row.LastWashAt.Time
+	}
+	return u, nil
+}
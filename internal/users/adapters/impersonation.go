@@ -0,0 +1,227 @@
+package adapters
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultImpersonationTTL = 30 * time.Minute
+	// impersonatorCookieName holds the admin's own session token while they
+	// browse as a member, so stopping puts them back where they were.
+	impersonatorCookieName = "impersonator_session"
+)
+
+type startImpersonationReq struct {
+	Reason string `json:"reason"`
+}
+
+type impersonationOut struct {
+	Token     string `json:"token"`
+	UserID    int64  `json:"userId"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// impersonationTTL is the hard lifetime of an impersonation session (IMPERSONATION_TTL_MINUTES).
+func impersonationTTL() time.Duration {
+	if s := strings.TrimSpace(os.Getenv("IMPERSONATION_TTL_MINUTES")); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return time.Duration(n) * time.Minute
+		}
+	}
+	return defaultImpersonationTTL
+}
+
+// StartImpersonation opens a session as the member for the calling admin.
+// Only accounts whose role grants no permissions can be impersonated, so
+// this can't be used to borrow another staff member's access.
+func (a *AdminAPIService) StartImpersonation(c echo.Context) error {
+	admin, _ := principalFrom(c)
+	if admin.ImpersonatorID > 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "already impersonating"})
+	}
+
+	idStr := c.Param("id")
+	uid, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || uid <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+	if uid == admin.UserID {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "cannot impersonate yourself"})
+	}
+
+	var req startImpersonationReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "reason is required"})
+	}
+
+	var role string
+	if err := a.db.Get(&role, a.db.Rebind(`SELECT role FROM users WHERE id = ? LIMIT 1`), uid); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "member not found"})
+	}
+	if len(loadRolePermissions(a.db, role)) > 0 {
+		a.audit(c, "impersonation.denied", "user", idStr, map[string]any{"role": role})
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "only members can be impersonated"})
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not start session"})
+	}
+	token := hex.EncodeToString(buf)
+	ttl := impersonationTTL()
+	expiresAt := time.Now().UTC().Add(ttl)
+
+	ua := truncateRunes(c.Request().UserAgent(), 512)
+	var sessionID int64
+	q := a.db.Rebind(`
+		INSERT INTO sessions (token, user_id, expires_at, last_seen_at, user_agent, ip, impersonator_id, impersonation_reason)
+		VALUES (?, ?, ?, NOW(), ?, ?, ?, ?)
+		RETURNING id
+	`)
+	if err := a.db.Get(&sessionID, q, token, uid, expiresAt, ua, c.RealIP(), admin.UserID, req.Reason); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	a.audit(c, "impersonation.start", "user", idStr, map[string]any{
+		"reason":    req.Reason,
+		"sessionId": sessionID,
+		"expiresAt": expiresAt.Format(time.RFC3339),
+	})
+
+	// Browser admins switch over by cookie; API clients use the returned token.
+	if _, fromCookie := sessionTokenFromRequest(c); fromCookie {
+		secure := os.Getenv("ENV") == "production"
+		c.SetCookie(&http.Cookie{
+			Name:     impersonatorCookieName,
+			Value:    admin.Token,
+			Path:     "/",
+			MaxAge:   int(sessionTTL() / time.Second),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   secure,
+		})
+		c.SetCookie(&http.Cookie{
+			Name:     sessionCookieName,
+			Value:    token,
+			Path:     "/",
+			Expires:  expiresAt,
+			MaxAge:   int(ttl / time.Second),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   secure,
+		})
+	}
+
+	return c.JSON(http.StatusOK, impersonationOut{Token: token, UserID: uid, ExpiresAt: expiresAt.Format(time.RFC3339)})
+}
+
+// GetMyImpersonation tells the app whether to show the impersonation banner.
+func (m *MeAPIService) GetMyImpersonation(c echo.Context) error {
+	p, _ := principalFrom(c)
+	if p.ImpersonatorID == 0 {
+		return c.JSON(http.StatusOK, map[string]any{"active": false})
+	}
+	var row struct {
+		ExpiresAt string `db:"expires_at"`
+		AdminName string `db:"admin_username"`
+		Reason    string `db:"impersonation_reason"`
+	}
+	_ = m.db.Get(&row, m.db.Rebind(`
+		SELECT COALESCE(s.expires_at::text,'') AS expires_at,
+		       COALESCE(u.username,'') AS admin_username,
+		       s.impersonation_reason
+		FROM sessions s
+		LEFT JOIN users u ON u.id = s.impersonator_id
+		WHERE s.id = ?
+	`), p.SessionID)
+	return c.JSON(http.StatusOK, map[string]any{
+		"active":         true,
+		"impersonatorId": p.ImpersonatorID,
+		"impersonator":   row.AdminName,
+		"reason":         row.Reason,
+		"expiresAt":      row.ExpiresAt,
+	})
+}
+
+// StopMyImpersonation ends the impersonation session and, for browsers,
+// restores the admin's own session cookie.
+func (m *MeAPIService) StopMyImpersonation(c echo.Context) error {
+	p, _ := principalFrom(c)
+	if p.ImpersonatorID == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "not impersonating"})
+	}
+	if _, err := m.db.Exec(m.db.Rebind(`DELETE FROM sessions WHERE id = ?`), p.SessionID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	writeAudit(m.db, p.ImpersonatorID, "impersonation.stop", "user", strconv.FormatInt(p.UserID, 10), map[string]any{
+		"sessionId": p.SessionID,
+	})
+
+	if ck, err := c.Cookie(impersonatorCookieName); err == nil && ck.Value != "" {
+		if _, ok := lookupSession(m.db, ck.Value); ok {
+			setSessionCookie(c, ck.Value)
+		} else {
+			clearSessionCookies(c)
+		}
+		clearImpersonatorCookie(c)
+	}
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+// restoreImpersonatorSession falls back to the admin's saved session once
+// the impersonation cookie stops resolving (expired or deleted).
+func restoreImpersonatorSession(c echo.Context, db *sqlx.DB) (string, activeSession, bool) {
+	ck, err := c.Cookie(impersonatorCookieName)
+	if err != nil || ck.Value == "" {
+		return "", activeSession{}, false
+	}
+	clearImpersonatorCookie(c)
+	sess, ok := lookupSession(db, ck.Value)
+	if !ok || sess.ImpersonatorID > 0 {
+		return "", activeSession{}, false
+	}
+	setSessionCookie(c, ck.Value)
+	return ck.Value, sess, true
+}
+
+func clearImpersonatorCookie(c echo.Context) {
+	c.SetCookie(&http.Cookie{
+		Name:     impersonatorCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   os.Getenv("ENV") == "production",
+	})
+}
+
+// auditImpersonatedRequest records what an admin did while acting as a
+// member: every API call and every non-GET page request.
+func auditImpersonatedRequest(c echo.Context, db *sqlx.DB, sess activeSession) {
+	req := c.Request()
+	path := req.URL.Path
+	if !strings.HasPrefix(path, "/api/") && req.Method == http.MethodGet {
+		return
+	}
+	writeAudit(db, sess.ImpersonatorID, "impersonation.action", "user", strconv.FormatInt(sess.UserID, 10), map[string]any{
+		"sessionId": sess.ID,
+		"method":    req.Method,
+		"path":      path,
+		"status":    c.Response().Status,
+	})
+}
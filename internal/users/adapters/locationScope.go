@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// AllLocations reports whether the caller is not restricted to assigned sites.
+func (p Principal) AllLocations() bool {
+	return p.Can(core.PermLocationsAll)
+}
+
+func (p Principal) CanAccessLocation(locationID string) bool {
+	if p.AllLocations() {
+		return true
+	}
+	for _, id := range p.Locations {
+		if id == locationID {
+			return true
+		}
+	}
+	return false
+}
+
+// locationScope is nil for unrestricted callers, otherwise their assigned locations.
+func (p Principal) locationScope() []string {
+	if p.AllLocations() {
+		return nil
+	}
+	if p.Locations == nil {
+		return []string{}
+	}
+	return p.Locations
+}
+
+func loadUserLocations(db *sqlx.DB, userID int64) []string {
+	locs := []string{}
+	q := db.Rebind(`SELECT location_id FROM user_locations WHERE user_id = ? ORDER BY location_id`)
+	_ = db.Select(&locs, q, userID)
+	return locs
+}
+
+// scopedLocations resolves the ?locationId filter against the caller's
+// assignments. locs is nil when no location filter applies; otherwise it is
+// the set of locations to restrict to (possibly empty). ok is false when the
+// caller asked for a location they aren't assigned to.
+func scopedLocations(c echo.Context) (locationID string, locs []string, ok bool) {
+	locationID = strings.TrimSpace(c.QueryParam("locationId"))
+	if locationID == "all" {
+		locationID = ""
+	}
+	p, _ := principalFrom(c)
+
+	if locationID != "" {
+		if !p.CanAccessLocation(locationID) {
+			return locationID, nil, false
+		}
+		return locationID, []string{locationID}, true
+	}
+	return "", p.locationScope(), true
+}
+
+// locationInClause builds " AND <column> IN (...)" for locs. A nil slice
+// means no filter; an empty one matches nothing.
+func locationInClause(column string, locs []string) (string, []any) {
+	if locs == nil {
+		return "", nil
+	}
+	if len(locs) == 0 {
+		return " AND 1 = 0", nil
+	}
+	args := make([]any, 0, len(locs))
+	for _, l := range locs {
+		args = append(args, l)
+	}
+	return " AND " + column + " IN (?" + strings.Repeat(", ?", len(locs)-1) + ")", args
+}
@@ -0,0 +1,142 @@
+package adapters
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	throttleScopeUser = "user"
+	throttleScopeIP   = "ip"
+
+	// Failures are forgotten after this long without another one.
+	throttleWindow  = 24 * time.Hour
+	throttleMaxLock = time.Hour
+)
+
+// Free attempts before a lock kicks in; each failure after that doubles it.
+var throttleFreeAttempts = map[string]int{
+	throttleScopeUser: 5,
+	throttleScopeIP:   20,
+}
+
+// LoginThrottle tracks failed sign-ins per account and per IP and locks
+// both out with exponential backoff.
+type LoginThrottle struct {
+	db *sqlx.DB
+}
+
+func NewLoginThrottle(db *sqlx.DB) *LoginThrottle {
+	return &LoginThrottle{db: db}
+}
+
+// lockDuration is 1m, 2m, 4m, ... once free attempts are used up, capped at throttleMaxLock.
+func lockDuration(scope string, failures int) time.Duration {
+	over := failures - throttleFreeAttempts[scope]
+	if over < 0 {
+		return 0
+	}
+	d := time.Minute * time.Duration(math.Pow(2, float64(min(over, 10))))
+	return min(d, throttleMaxLock)
+}
+
+// userIDFor maps a username to its id, or 0 if there is no such user.
+func (t *LoginThrottle) userIDFor(username string) int64 {
+	var uid int64
+	_ = t.db.Get(&uid, t.db.Rebind(`SELECT id FROM users WHERE username = ? LIMIT 1`), username)
+	return uid
+}
+
+// RetryAfter reports how long the account or IP must wait before the next
+// attempt; zero means go ahead. The password must not be checked while locked.
+func (t *LoginThrottle) RetryAfter(uid int64, ip string) time.Duration {
+	var until time.Time
+	q := t.db.Rebind(`
+		SELECT COALESCE(MAX(locked_until), NOW())
+		FROM login_throttle
+		WHERE ((scope = 'user' AND key = ?) OR (scope = 'ip' AND key = ?))
+		  AND locked_until > NOW()
+	`)
+	if err := t.db.Get(&until, q, strconv.FormatInt(uid, 10), ip); err != nil {
+		return 0
+	}
+	if d := time.Until(until); d > 0 {
+		return d.Round(time.Second)
+	}
+	return 0
+}
+
+// RecordFailure counts a failed attempt against the IP and, when known, the
+// account. A new lock is written to the audit log.
+func (t *LoginThrottle) RecordFailure(uid int64, ip string) {
+	if uid > 0 {
+		t.fail(throttleScopeUser, strconv.FormatInt(uid, 10), ip)
+	}
+	if ip != "" {
+		t.fail(throttleScopeIP, ip, ip)
+	}
+}
+
+func (t *LoginThrottle) fail(scope, key, ip string) {
+	var failures int
+	q := t.db.Rebind(`
+		INSERT INTO login_throttle (scope, key, failures, last_failed_at)
+		VALUES (?, ?, 1, NOW())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN login_throttle.last_failed_at < NOW() - (? * INTERVAL '1 second')
+			                THEN 1 ELSE login_throttle.failures + 1 END,
+			last_failed_at = NOW()
+		RETURNING failures
+	`)
+	if err := t.db.Get(&failures, q, scope, key, int(throttleWindow/time.Second)); err != nil {
+		return
+	}
+
+	lock := lockDuration(scope, failures)
+	if lock == 0 {
+		return
+	}
+	until := time.Now().UTC().Add(lock)
+	_, _ = t.db.Exec(t.db.Rebind(`UPDATE login_throttle SET locked_until = ? WHERE scope = ? AND key = ?`), until, scope, key)
+
+	detail := map[string]any{"failures": failures, "lockedUntil": until.Format(time.RFC3339), "ip": ip}
+	if scope == throttleScopeUser {
+		// Actor 0: raised by the system, not an admin.
+		writeAudit(t.db, 0, "user.lockout", "user", key, detail)
+	} else {
+		writeAudit(t.db, 0, "ip.lockout", "ip", key, detail)
+	}
+}
+
+// RecordSuccess clears the account's counter. The IP counter is left to
+// decay so one good login can't reset a spray from the same address.
+func (t *LoginThrottle) RecordSuccess(uid int64) {
+	_, _ = t.db.Exec(t.db.Rebind(`DELETE FROM login_throttle WHERE scope = 'user' AND key = ?`), strconv.FormatInt(uid, 10))
+}
+
+// Unlock clears an account lock. It reports whether there was anything to clear.
+func (t *LoginThrottle) Unlock(uid int64) (bool, error) {
+	res, err := t.db.Exec(t.db.Rebind(`DELETE FROM login_throttle WHERE scope = 'user' AND key = ?`), strconv.FormatInt(uid, 10))
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func lockoutMessage(wait time.Duration) string {
+	mins := int(math.Ceil(wait.Minutes()))
+	if mins <= 1 {
+		return "Too many failed attempts. Try again in a minute."
+	}
+	return fmt.Sprintf("Too many failed attempts. Try again in %d minutes.", mins)
+}
+
+func setRetryAfter(c echo.Context, wait time.Duration) {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
@@ -0,0 +1,95 @@
+package adapters
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/edlingao/hexago/internal/users/ports"
+	"github.com/google/uuid"
+)
+
+// NewMailerFromEnv picks the mail adapter from MAIL_DRIVER:
+// "smtp" uses SMTP_HOST/SMTP_PORT/SMTP_USERNAME/SMTP_PASSWORD, anything else
+// writes messages to MAIL_DIR (or the log when MAIL_DIR is empty).
+func NewMailerFromEnv() ports.SendingMail {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+	if strings.EqualFold(os.Getenv("MAIL_DRIVER"), "smtp") {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPMailer{
+			host:     os.Getenv("SMTP_HOST"),
+			port:     port,
+			username: os.Getenv("SMTP_USERNAME"),
+			password: os.Getenv("SMTP_PASSWORD"),
+			from:     from,
+		}
+	}
+	return &FileMailer{dir: os.Getenv("MAIL_DIR"), from: from}
+}
+
+// SMTPMailer implements ports.SendingMail over plain SMTP with STARTTLS when offered.
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func (m *SMTPMailer) Send(msg core.MailMessage) error {
+	if m.host == "" {
+		return fmt.Errorf("smtp: SMTP_HOST not configured")
+	}
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	return smtp.SendMail(net.JoinHostPort(m.host, m.port), auth, m.from, []string{msg.To}, formatMail(m.from, msg))
+}
+
+// FileMailer implements ports.SendingMail for local development. Messages
+// are written as .eml files under dir, or logged when dir is empty.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func (m *FileMailer) Send(msg core.MailMessage) error {
+	raw := formatMail(m.from, msg)
+	if m.dir == "" {
+		log.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format("20060102T150405") + "-" + uuid.NewString()[:8] + ".eml"
+	return os.WriteFile(filepath.Join(m.dir, name), raw, 0o644)
+}
+
+// Header values come from user input (the address), so line breaks are dropped.
+var mailHeaderReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+func formatMail(from string, msg core.MailMessage) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + mailHeaderReplacer.Replace(from) + "\r\n")
+	b.WriteString("To: " + mailHeaderReplacer.Replace(msg.To) + "\r\n")
+	b.WriteString("Subject: " + mailHeaderReplacer.Replace(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().UTC().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
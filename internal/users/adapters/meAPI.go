@@ -1,13 +1,18 @@
 package adapters
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 
 	"fmt"
 	"time"
 
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/edlingao/hexago/internal/users/ports"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 )
@@ -15,14 +20,23 @@ import (
 type MeAPIService struct {
 	httpService *echo.Group
 	db          *sqlx.DB
+	qr          *qrSigner
+	twoFactor   *TwoFactor
+	payments    ports.TakingPayments
 }
 
 func NewMeAPIService(httpService *echo.Group) *MeAPIService {
-	return &MeAPIService{httpService: httpService}
+	return &MeAPIService{httpService: httpService, qr: newQRSignerFromEnv(), payments: &ManualPayments{}}
 }
 
 func (m *MeAPIService) WithDB(db *sqlx.DB) *MeAPIService {
 	m.db = db
+	m.twoFactor = NewTwoFactor(db)
+	return m
+}
+
+func (m *MeAPIService) WithPayments(payments ports.TakingPayments) *MeAPIService {
+	m.payments = payments
 	return m
 }
 
@@ -30,12 +44,31 @@ func (m *MeAPIService) RegisterRoutes() {
 	m.httpService.GET("/me", m.GetMe)
 	m.httpService.PUT("/me", m.UpdateMe)
 	m.httpService.GET("/me/subscription", m.GetMySubscription)
-	m.httpService.POST("/me/subscription", m.SetMySubscription)
+	m.httpService.POST("/me/subscription", m.SetMySubscription, RequireAuth, DenyImpersonation)
 	m.httpService.GET("/me/history", m.GetMyHistoryV2)
+	m.httpService.GET("/me/qr", m.GetMyQR)
+	m.httpService.GET("/me/locations", m.ListMyLocations)
 	m.httpService.GET("/me/cars", m.ListMyCars)
 	m.httpService.POST("/me/cars", m.CreateMyCar)
 	m.httpService.PUT("/me/cars/:id", m.UpdateMyCar)
 	m.httpService.DELETE("/me/cars/:id", m.DeleteMyCar)
+	m.httpService.GET("/me/sessions", m.ListMySessions)
+	m.httpService.DELETE("/me/sessions", m.RevokeMySessions, DenyImpersonation)
+	m.httpService.DELETE("/me/sessions/:id", m.RevokeMySession, DenyImpersonation)
+	m.httpService.GET("/me/2fa", m.GetMyTwoFactor, RequireAuth)
+	m.httpService.POST("/me/2fa/enroll", m.EnrollMyTwoFactor, RequireAuth, DenyImpersonation)
+	m.httpService.POST("/me/2fa/confirm", m.ConfirmMyTwoFactor, RequireAuth, DenyImpersonation)
+	m.httpService.POST("/me/2fa/recovery-codes", m.RegenerateMyRecoveryCodes, RequireAuth, DenyImpersonation)
+	m.httpService.DELETE("/me/2fa", m.DisableMyTwoFactor, RequireAuth, DenyImpersonation)
+	m.httpService.GET("/me/impersonation", m.GetMyImpersonation, RequireAuth)
+	m.httpService.DELETE("/me/impersonation", m.StopMyImpersonation, RequireAuth)
+	m.httpService.GET("/me/payment-methods", m.ListMyPaymentMethods, RequireAuth)
+	m.httpService.POST("/me/payment-methods", m.AddMyPaymentMethod, RequireAuth, DenyImpersonation)
+	m.httpService.DELETE("/me/payment-methods/:id", m.DeleteMyPaymentMethod, RequireAuth, DenyImpersonation)
+	m.httpService.GET("/me/payments", m.ListMyPayments, RequireAuth)
+	m.httpService.POST("/me/subscription/cancel", m.CancelMySubscription, RequireAuth, DenyImpersonation)
+	m.httpService.POST("/me/subscription/pause", m.PauseMySubscription, RequireAuth, DenyImpersonation)
+	m.httpService.POST("/me/subscription/reactivate", m.ReactivateMySubscription, RequireAuth, DenyImpersonation)
 
 }
 
@@ -46,6 +79,8 @@ type meRow struct {
 	FirstName string `db:"first_name" json:"firstName"`
 	LastName  string `db:"last_name" json:"lastName"`
 	AvatarURL string `db:"avatar_url" json:"avatarUrl"`
+
+	EmailVerified bool `db:"email_verified" json:"emailVerified"`
 }
 
 type updateMeRequest struct {
@@ -56,12 +91,23 @@ type updateMeRequest struct {
 }
 
 type subscriptionOut struct {
+	ID              string `json:"id" db:"id"`
+	CarID           string `json:"carId,omitempty" db:"car_id"`
 	PlanID          string `json:"planId" db:"plan_id"`
 	PlanName        string `json:"planName" db:"plan_name"`
 	PriceCents      int    `json:"priceCents" db:"price_cents"`
 	FeaturesJSON    string `json:"featuresJson" db:"features_json"`
 	Status          string `json:"status" db:"status"`
 	NextBillingDate string `json:"nextBillingDate" db:"next_billing_date"`
+	// A plan change scheduled for NextBillingDate, and credit from an
+	// immediate downgrade that comes off the next renewals.
+	PendingPlanID   string `json:"pendingPlanId,omitempty" db:"pending_plan_id"`
+	PendingPlanName string `json:"pendingPlanName,omitempty" db:"pending_plan_name"`
+	CreditCents     int    `json:"creditCents" db:"credit_cents"`
+	// CancelAtPeriodEnd means the subscription ends on NextBillingDate
+	// instead of renewing; PausedUntil is set while it is paused.
+	CancelAtPeriodEnd bool   `json:"cancelAtPeriodEnd" db:"cancel_at_period_end"`
+	PausedUntil       string `json:"pausedUntil,omitempty" db:"paused_until"`
 }
 
 func (m *MeAPIService) GetMe(c echo.Context) error {
@@ -75,7 +121,7 @@ func (m *MeAPIService) GetMe(c echo.Context) error {
 	}
 
 	var u meRow
-	if err := m.db.Get(&u, m.db.Rebind(`SELECT id, username, email, first_name, last_name, avatar_url FROM users WHERE id = ? LIMIT 1`), uid); err != nil {
+	if err := m.db.Get(&u, m.db.Rebind(`SELECT id, username, email, first_name, last_name, avatar_url, (email_verified_at IS NOT NULL) AS email_verified FROM users WHERE id = ? LIMIT 1`), uid); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, u)
@@ -101,27 +147,34 @@ func (m *MeAPIService) UpdateMe(c echo.Context) error {
 	req.LastName = strings.TrimSpace(req.LastName)
 	req.AvatarURL = strings.TrimSpace(req.AvatarURL)
 
+	// The address is where password resets go, so an impersonating admin can't change it.
+	if p, _ := principalFrom(c); p.ImpersonatorID > 0 {
+		req.Email = ""
+	}
+
 	// Minimal validation for now
 	if req.Email != "" && !strings.Contains(req.Email, "@") {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid email"})
 	}
 
+	// A new address has to be verified again.
 	q := m.db.Rebind(`
 		UPDATE users
-		SET email = COALESCE(?, email),
+		SET email_verified_at = CASE WHEN LOWER(COALESCE(?::text, email)) = LOWER(email) THEN email_verified_at ELSE NULL END,
+		    email = COALESCE(?, email),
 		    first_name = COALESCE(?, first_name),
 		    last_name = COALESCE(?, last_name),
 		    avatar_url = COALESCE(?, avatar_url)
 		WHERE id = ?
 	`)
 
-	_, err := m.db.Exec(q, nullIfEmpty(req.Email), nullIfEmpty(req.FirstName), nullIfEmpty(req.LastName), nullIfEmpty(req.AvatarURL), uid)
+	_, err := m.db.Exec(q, nullIfEmpty(req.Email), nullIfEmpty(req.Email), nullIfEmpty(req.FirstName), nullIfEmpty(req.LastName), nullIfEmpty(req.AvatarURL), uid)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "update failed"})
 	}
 
 	var u meRow
-	_ = m.db.Get(&u, m.db.Rebind(`SELECT id, username, email, first_name, last_name, avatar_url FROM users WHERE id = ? LIMIT 1`), uid)
+	_ = m.db.Get(&u, m.db.Rebind(`SELECT id, username, email, first_name, last_name, avatar_url, (email_verified_at IS NOT NULL) AS email_verified FROM users WHERE id = ? LIMIT 1`), uid)
 	return c.JSON(http.StatusOK, u)
 }
 
@@ -135,79 +188,126 @@ func (m *MeAPIService) GetMySubscription(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
 	}
 
-	var out subscriptionOut
-	err := m.db.Get(&out, m.db.Rebind(`
-		SELECT s.plan_id,
+	subs, err := memberSubscriptions(m.db, uid)
+	if err != nil {
+		subs = []subscriptionOut{}
+	}
+	// subscription is the first one, for clients that predate per-car plans.
+	active := slices.ContainsFunc(subs, func(s subscriptionOut) bool { return s.Status == core.SubscriptionActive })
+	out := map[string]any{"active": active, "subscription": nil, "subscriptions": subs, "cancelReasons": core.CancelReasons}
+	if len(subs) > 0 {
+		out["subscription"] = subs[0]
+	}
+	if timeline, err := subscriptionTimeline(m.db, uid); err == nil {
+		out["timeline"] = timeline
+	}
+
+	// ?planId (with ?carId and ?timing as for SetMySubscription) previews
+	// what switching would cost before the member confirms.
+	if planID := strings.TrimSpace(c.QueryParam("planId")); planID != "" {
+		timing := strings.TrimSpace(c.QueryParam("timing"))
+		if timing != "" && timing != core.ChangeImmediate && timing != core.ChangePeriodEnd {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "timing must be immediate or period_end"})
+		}
+		t, status, msg := m.subscriptionTarget(uid, planID, strings.TrimSpace(c.QueryParam("carId")))
+		if status != 0 {
+			return c.JSON(status, map[string]string{"error": msg})
+		}
+		cur, err := loadSubscriptionState(m.db, t.SubID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		out["preview"] = previewPlanChange(cur, t, planID, timing, time.Now())
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// memberSubscriptions lists the member's subscriptions that haven't been
+// canceled, account-wide first.
+func memberSubscriptions(db *sqlx.DB, uid int) ([]subscriptionOut, error) {
+	out := []subscriptionOut{}
+	err := db.Select(&out, db.Rebind(`
+		SELECT s.id,
+		       COALESCE(s.car_id, '') as car_id,
+		       s.plan_id,
 		       p.name as plan_name,
-		       p.price_cents,
+		       COALESCE(s.price_cents, p.price_cents) as price_cents,
 		       p.features_json,
 		       s.status,
-		       s.next_billing_date
+		       s.next_billing_date,
+		       COALESCE(s.pending_plan_id, '') AS pending_plan_id,
+		       COALESCE(pp.name, '') AS pending_plan_name,
+		       s.credit_cents,
+		       s.cancel_at_period_end,
+		       COALESCE(s.paused_until, '') AS paused_until
 		FROM subscriptions s
 		JOIN plans p ON p.id = s.plan_id
-		WHERE s.user_id = ? AND s.status = 'active'
-		LIMIT 1
+		LEFT JOIN plans pp ON pp.id = s.pending_plan_id
+		WHERE s.user_id = ? AND s.status <> 'canceled'
+		ORDER BY s.car_id NULLS FIRST, s.start_date
 	`), uid)
+	return out, err
+}
 
-	if err != nil {
-		return c.JSON(http.StatusOK, map[string]any{"active": false, "subscription": nil})
-	}
-
-	return c.JSON(http.StatusOK, map[string]any{"active": true, "subscription": out})
+type myQROut struct {
+	QR         string `json:"qr"`
+	ExpiresAt  string `json:"expiresAt"`
+	TTLSeconds int    `json:"ttlSeconds"`
 }
 
-// --- auth helpers ---
-// Accept token from Authorization: Bearer <token>, or X-Session-Token, or cookie "token"/"session_token".
-func (m *MeAPIService) authedUserID(c echo.Context) (int, bool) {
-	env := os.Getenv("ENV")
-
-	// DEV-only escape hatch (optional): X-Demo-UserId
-	if env != "production" {
-		if demo := c.Request().Header.Get("X-Demo-UserId"); demo != "" {
-			uid := 0
-			for _, ch := range demo {
-				if ch < '0' || ch > '9' {
-					uid = 0
-					break
-				}
-				uid = uid*10 + int(ch-'0')
-			}
-			if uid > 0 {
-				return uid, true
-			}
-		}
+// GetMyQR issues a short-lived, signed membership QR payload. Clients should
+// render it as-is and call this again when it expires.
+func (m *MeAPIService) GetMyQR(c echo.Context) error {
+	if m.db == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db not configured"})
 	}
 
-	token := ""
-	auth := c.Request().Header.Get("Authorization")
-	if strings.HasPrefix(auth, "Bearer ") {
-		token = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
-	}
-	if token == "" {
-		token = strings.TrimSpace(c.Request().Header.Get("X-Session-Token"))
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
 	}
-	if token == "" {
-		if ck, err := c.Cookie("session_token"); err == nil {
-			token = ck.Value
-		}
+
+	now := time.Now().UTC()
+	qr, expiresAt, err := m.qr.Issue(uid, now)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to issue qr"})
 	}
-	if token == "" {
-		if ck, err := c.Cookie("token"); err == nil {
-			token = ck.Value
-		}
+
+	return c.JSON(http.StatusOK, myQROut{
+		QR:         qr,
+		ExpiresAt:  expiresAt.Format(time.RFC3339),
+		TTLSeconds: int(m.qr.ttl / time.Second),
+	})
+}
+
+// ListMyLocations returns the locations the caller may scan at and report on.
+func (m *MeAPIService) ListMyLocations(c echo.Context) error {
+	if m.db == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db not configured"})
 	}
-	if token == "" {
-		return 0, false
+
+	p, ok := principalFrom(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
 	}
 
-	type sessRow struct {
-		UserID int `db:"user_id"`
+	locIn, locArgs := locationInClause("id", p.locationScope())
+	q := m.db.Rebind(`SELECT id, name, COALESCE(address,'') AS address FROM locations WHERE 1 = 1` + locIn + ` ORDER BY name ASC`)
+	locs := []AdminLocation{}
+	if err := m.db.Select(&locs, q, locArgs...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
-	var s sessRow
-	if err := m.db.Get(&s, m.db.Rebind(`SELECT user_id FROM sessions WHERE token = ? LIMIT 1`), token); err != nil {
+	return c.JSON(http.StatusOK, map[string]any{"locations": locs, "allLocations": p.AllLocations()})
+}
+
+// --- auth helpers ---
+// The session itself is resolved by AuthMiddleware.Resolve.
+func (m *MeAPIService) authedUserID(c echo.Context) (int, bool) {
+	p, ok := principalFrom(c)
+	if !ok {
 		return 0, false
 	}
-	return s.UserID, true
+	return int(p.UserID), true
 }
 
 func nullIfEmpty(s string) any {
@@ -266,6 +366,77 @@ func (m *MeAPIService) GetMyHistory(c echo.Context) error {
 
 type setSubReq struct {
 	PlanID string `json:"planId"`
+	// CarID is the car to put on the plan. It can be left out when the
+	// member has at most one car.
+	CarID string `json:"carId"`
+	// Timing is "immediate" or "period_end" when the car is already on a
+	// plan. Left out, upgrades apply now and downgrades at the period end.
+	Timing string `json:"timing"`
+}
+
+// subscriptionTarget is the subscription row a choice of plan applies to.
+type subscriptionTarget struct {
+	SubID string
+	CarID string
+	// PriceCents is the family price locked in, nil for the plan's own.
+	PriceCents  *int
+	AmountCents int
+}
+
+// subscriptionTarget resolves the plan and car a member picked. On a bad
+// choice it returns the status and message to answer with.
+func (m *MeAPIService) subscriptionTarget(uid int, planID, carID string) (subscriptionTarget, int, string) {
+	var plan struct {
+		PriceCents         int  `db:"price_cents"`
+		ExtraCarPriceCents *int `db:"extra_car_price_cents"`
+	}
+	q1 := m.db.Rebind(`SELECT price_cents, extra_car_price_cents FROM plans WHERE id = ? LIMIT 1`)
+	if err := m.db.Get(&plan, q1, planID); err != nil {
+		return subscriptionTarget{}, http.StatusBadRequest, "invalid planId"
+	}
+
+	if carID == "" {
+		var carIDs []string
+		if err := m.db.Select(&carIDs, m.db.Rebind(`SELECT id FROM cars WHERE user_id = ? LIMIT 2`), uid); err != nil {
+			return subscriptionTarget{}, http.StatusInternalServerError, err.Error()
+		}
+		switch len(carIDs) {
+		case 0:
+			// No car yet: an account-wide subscription, bound once they add one.
+		case 1:
+			carID = carIDs[0]
+		default:
+			return subscriptionTarget{}, http.StatusBadRequest, "carId is required when you have more than one car"
+		}
+	} else {
+		var owned int
+		if err := m.db.Get(&owned, m.db.Rebind(`SELECT COUNT(1) FROM cars WHERE id = ? AND user_id = ?`), carID, uid); err != nil || owned == 0 {
+			return subscriptionTarget{}, http.StatusNotFound, "car not found"
+		}
+	}
+
+	// Reuse the car's current subscription, or the member's account-wide one
+	// so moving to per-car plans doesn't bill them twice. Otherwise SubID is
+	// empty and subscribing adds a new row, keeping earlier ones on record.
+	t := subscriptionTarget{CarID: carID, AmountCents: plan.PriceCents}
+	q := m.db.Rebind(`
+		SELECT id FROM subscriptions
+		WHERE user_id = ? AND status <> 'canceled' AND (car_id = ? OR car_id IS NULL)
+		ORDER BY car_id IS NULL, status = 'active' DESC
+		LIMIT 1
+	`)
+	_ = m.db.Get(&t.SubID, q, uid, carID)
+	if carID != "" {
+		// Family pricing applies from the member's second car on the plan.
+		var others int
+		q = m.db.Rebind(`SELECT COUNT(1) FROM subscriptions WHERE user_id = ? AND plan_id = ? AND status = 'active' AND car_id IS NOT NULL AND id <> ?`)
+		_ = m.db.Get(&others, q, uid, planID, t.SubID)
+		if others > 0 && plan.ExtraCarPriceCents != nil {
+			t.PriceCents = plan.ExtraCarPriceCents
+			t.AmountCents = *plan.ExtraCarPriceCents
+		}
+	}
+	return t, 0, ""
 }
 
 func (m *MeAPIService) SetMySubscription(c echo.Context) error {
@@ -282,31 +453,138 @@ func (m *MeAPIService) SetMySubscription(c echo.Context) error {
 	if err := c.Bind(&req); err != nil || req.PlanID == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "planId required"})
 	}
+	req.CarID = strings.TrimSpace(req.CarID)
+	req.Timing = strings.TrimSpace(req.Timing)
+	if req.Timing != "" && req.Timing != core.ChangeImmediate && req.Timing != core.ChangePeriodEnd {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "timing must be immediate or period_end"})
+	}
+	return m.subscribe(c, uid, req)
+}
+
+// subscribe puts a car on a plan: a plan change when it already has an
+// active subscription, otherwise a new one with the first period charged.
+func (m *MeAPIService) subscribe(c echo.Context, uid int, req setSubReq) error {
+	// One purchase per member at a time: a double-submitted request waits
+	// here, then finds what the first one saved instead of sharing its charge.
+	lock, err := m.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer lock.Rollback()
+	if err := lockMemberBilling(lock, uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 
-	// Validate plan exists
-	var exists int
-	q1 := m.db.Rebind(`SELECT 1 FROM plans WHERE id = ? LIMIT 1`)
-	if err := m.db.Get(&exists, q1, req.PlanID); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid planId"})
+	target, status, msg := m.subscriptionTarget(uid, req.PlanID, req.CarID)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	cur, err := loadSubscriptionState(m.db, target.SubID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	switch cur.Status {
+	case core.SubscriptionActive:
+		return m.changePlan(c, uid, cur, target, req)
+	case core.SubscriptionPaused:
+		return c.JSON(http.StatusConflict, map[string]string{"error": "This subscription is paused; resume it before changing plans."})
+	case core.SubscriptionPastDue:
+		return c.JSON(http.StatusConflict, map[string]string{"error": "This subscription has a payment past due; reactivate it before changing plans."})
 	}
 
-	// Upsert one active subscription per user
-	subID := "sub-" + fmt.Sprint(uid)
 	start := time.Now().Format("2006-01-02")
 	next := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
 
-	q2 := m.db.Rebind(`
-		INSERT INTO subscriptions (id, user_id, plan_id, status, start_date, next_billing_date, wash_count)
-		VALUES (?, ?, ?, 'active', ?, ?, 0)
-		ON CONFLICT (id) DO UPDATE
-		SET plan_id = EXCLUDED.plan_id,
-		    status = 'active',
-		    start_date = EXCLUDED.start_date,
-		    next_billing_date = EXCLUDED.next_billing_date
+	// Every subscription is a row of its own; one that was canceled stays as
+	// it was. The counts keep the charge key stable across retries of this
+	// request without colliding with an earlier subscription the same day,
+	// or with an attempt whose charge was refunded because it didn't save.
+	var prior struct {
+		Total    int `db:"total"`
+		Canceled int `db:"canceled"`
+		Refunded int `db:"refunded"`
+	}
+	q := m.db.Rebind(`
+		SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE status = 'canceled') AS canceled,
+		       (SELECT COUNT(*) FROM payments WHERE user_id = ? AND status = 'refunded') AS refunded
+		FROM subscriptions
+		WHERE user_id = ?
 	`)
-	if _, err := m.db.Exec(q2, subID, uid, req.PlanID, start, next); err != nil {
+	if err := m.db.Get(&prior, q, uid, uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	subID := uuid.NewString()
+	reason := core.PeriodSubscribed
+	if prior.Canceled > 0 {
+		reason = core.PeriodResubscribed
+	}
+
+	// The first period is paid up front; a declined card subscribes nothing.
+	amount := target.AmountCents
+	var payment PaymentRow
+	if amount > 0 {
+		var err error
+		payment, err = chargeMember(m.db, m.payments, memberCharge{
+			UserID:         uid,
+			AmountCents:    amount,
+			Description:    "Subscription to " + req.PlanID,
+			IdempotencyKey: fmt.Sprintf("subscribe-%d-%d-%d-%s-%s-%s-%s", uid, prior.Total, prior.Refunded, target.CarID, req.PlanID, start, defaultPaymentMethodID(m.db, uid)),
+		})
+		if err != nil {
+			return c.JSON(paymentStatus(err), map[string]string{"error": err.Error()})
+		}
+	}
+
+	// The subscription, its first period and its history save together; if
+	// any of it fails the charge is given back.
+	if err := m.insertSubscription(uid, subID, target, req.PlanID, start, next, reason, payment); err != nil {
+		refundCharge(m.db, m.payments, payment)
+		if isUniqueViolation(err) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "This car already has a subscription."})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
 	return m.GetMySubscription(c)
 }
+
+// insertSubscription saves a new subscription with its first period and
+// history in one transaction, linking the charge that paid for it.
+func (m *MeAPIService) insertSubscription(uid int, subID string, t subscriptionTarget, planID, start, next, reason string, payment PaymentRow) error {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := tx.Rebind(`
+		INSERT INTO subscriptions (id, user_id, plan_id, status, start_date, next_billing_date, wash_count, car_id, price_cents)
+		VALUES (?, ?, ?, 'active', ?, ?, 0, NULLIF(?, ''), ?)
+	`)
+	if _, err := tx.Exec(q, subID, uid, planID, start, next, t.CarID, t.PriceCents); err != nil {
+		return err
+	}
+	if payment.ProviderChargeID != "" {
+		q = tx.Rebind(`UPDATE payments SET subscription_id = ? WHERE provider_charge_id = ?`)
+		if _, err := tx.Exec(q, subID, payment.ProviderChargeID); err != nil {
+			return err
+		}
+	}
+	if err := startSubscriptionPeriod(tx, subID, start, reason); err != nil {
+		return err
+	}
+	if err := recordSubscriptionEvent(tx, subscriptionEvent{
+		SubscriptionID: subID,
+		UserID:         uid,
+		Event:          "subscribed",
+		ToStatus:       core.SubscriptionActive,
+		AmountCents:    &payment.AmountCents,
+		ChargeID:       payment.ProviderChargeID,
+		Detail:         planID,
+		PeriodStart:    start,
+		PeriodEnd:      next,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -22,6 +22,12 @@ type carRow struct {
 	Plate     string `db:"plate" json:"plate"`
 	CreatedAt string `db:"created_at" json:"createdAt"`
 	UpdatedAt string `db:"updated_at" json:"updatedAt"`
+
+	// PlanStatus is the status of the car's own subscription ("active",
+	// "paused" or "past_due"), "account" when an account-wide one covers it,
+	// or "none". Only ListMyCars fills it.
+	PlanStatus   string           `db:"-" json:"planStatus,omitempty"`
+	Subscription *subscriptionOut `db:"-" json:"subscription,omitempty"`
 }
 
 type carUpsertReq struct {
@@ -65,6 +71,22 @@ func (m *MeAPIService) ListMyCars(c echo.Context) error {
 	if cars == nil {
 		cars = []carRow{}
 	}
+	subs, err := memberSubscriptions(m.db, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	for i := range cars {
+		cars[i].PlanStatus = "none"
+		for j := range subs {
+			if subs[j].CarID == cars[i].ID {
+				cars[i].PlanStatus, cars[i].Subscription = "active", &subs[j]
+				break
+			}
+			if subs[j].CarID == "" && cars[i].Subscription == nil {
+				cars[i].PlanStatus, cars[i].Subscription = "account", &subs[j]
+			}
+		}
+	}
 	return c.JSON(http.StatusOK, map[string]any{"cars": cars})
 }
 
@@ -228,6 +250,12 @@ func (m *MeAPIService) DeleteMyCar(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing car id"})
 	}
 
+	var subscribed int
+	qSub := m.db.Rebind(`SELECT COUNT(1) FROM subscriptions WHERE car_id = ? AND user_id = ? AND status <> 'canceled'`)
+	if err := m.db.Get(&subscribed, qSub, carID, uid); err == nil && subscribed > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "This car has a subscription; cancel it first."})
+	}
+
 	q := m.db.Rebind(`DELETE FROM cars WHERE id = ? AND user_id = ?`)
 	res, err := m.db.Exec(q, carID, uid)
 	if err != nil {
@@ -16,6 +16,10 @@ type meHistoryEvent struct {
 	LocationID      string `json:"locationId" db:"location_id"`
 	LocationName    string `json:"locationName" db:"location_name"`
 	LocationAddress string `json:"locationAddress" db:"location_address"`
+	Lane            string `json:"lane" db:"lane"`
+	DeviceName      string `json:"deviceName" db:"device_name"`
+	// ScannedBy is the attendant's first name only.
+	ScannedBy string `json:"scannedBy" db:"scanned_by"`
 }
 
 func (m *MeAPIService) GetMyHistoryV2(c echo.Context) error {
@@ -53,9 +57,14 @@ func (m *MeAPIService) GetMyHistoryV2(c echo.Context) error {
 			COALESCE(e.reason,'') AS reason,
 			COALESCE(e.location_id,'') AS location_id,
 			COALESCE(l.name,'') AS location_name,
-			COALESCE(l.address,'') AS location_address
+			COALESCE(l.address,'') AS location_address,
+			COALESCE(e.lane,'') AS lane,
+			COALESCE(d.name,'') AS device_name,
+			COALESCE(sb.first_name,'') AS scanned_by
 		FROM wash_events e
 		LEFT JOIN locations l ON l.id = e.location_id
+		LEFT JOIN scanner_devices d ON d.id = e.device_id
+		LEFT JOIN users sb ON sb.id = e.scanned_by
 		WHERE e.user_id = ?
 	`
 	args := []any{uid}
@@ -0,0 +1,135 @@
+package adapters
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/labstack/echo/v4"
+)
+
+type paymentMethodRow struct {
+	core.PaymentMethod
+	IsDefault bool      `db:"is_default" json:"isDefault"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+// paymentStatus maps a payment error to a response status: declines are the
+// member's to fix, anything else is the provider's.
+func paymentStatus(err error) int {
+	var decline *core.DeclineError
+	if errors.As(err, &decline) {
+		return http.StatusPaymentRequired
+	}
+	return http.StatusBadGateway
+}
+
+func (m *MeAPIService) ListMyPaymentMethods(c echo.Context) error {
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+	methods := []paymentMethodRow{}
+	q := m.db.Rebind(`
+		SELECT id, brand, last4, exp_month, exp_year, is_default, created_at
+		FROM payment_methods WHERE user_id = ?
+		ORDER BY is_default DESC, created_at DESC
+	`)
+	if err := m.db.Select(&methods, q, uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"paymentMethods": methods})
+}
+
+// AddMyPaymentMethod saves a card from a provider token collected in the
+// browser and makes it the default for future charges.
+func (m *MeAPIService) AddMyPaymentMethod(c echo.Context) error {
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.Bind(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "token required"})
+	}
+
+	customerID, _, err := paymentCustomer(m.db, m.payments, uid)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+	pm, err := m.payments.AttachPaymentMethod(customerID, strings.TrimSpace(req.Token))
+	if err != nil {
+		return c.JSON(paymentStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(tx.Rebind(`UPDATE payment_methods SET is_default = FALSE WHERE user_id = ? AND is_default`), uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	q := tx.Rebind(`
+		INSERT INTO payment_methods (id, user_id, brand, last4, exp_month, exp_year, is_default)
+		VALUES (?, ?, ?, ?, ?, ?, TRUE)
+	`)
+	if _, err := tx.Exec(q, pm.ID, uid, pm.Brand, pm.Last4, pm.ExpMonth, pm.ExpYear); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return m.ListMyPaymentMethods(c)
+}
+
+// DeleteMyPaymentMethod forgets a card; the newest remaining one becomes the default.
+func (m *MeAPIService) DeleteMyPaymentMethod(c echo.Context) error {
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+	id := strings.TrimSpace(c.Param("id"))
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(tx.Rebind(`DELETE FROM payment_methods WHERE id = ? AND user_id = ?`), id, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "payment method not found"})
+	}
+	q := tx.Rebind(`
+		UPDATE payment_methods SET is_default = TRUE
+		WHERE id = (SELECT id FROM payment_methods WHERE user_id = ? ORDER BY created_at DESC LIMIT 1)
+		  AND NOT EXISTS (SELECT 1 FROM payment_methods WHERE user_id = ? AND is_default)
+	`)
+	if _, err := tx.Exec(q, uid, uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return m.ListMyPaymentMethods(c)
+}
+
+func (m *MeAPIService) ListMyPayments(c echo.Context) error {
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+	payments := []PaymentRow{}
+	q := m.db.Rebind(paymentSelect + ` WHERE user_id = ? ORDER BY created_at DESC LIMIT 100`)
+	if err := m.db.Select(&payments, q, uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"payments": payments})
+}
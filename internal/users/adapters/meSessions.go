@@ -0,0 +1,103 @@
+package adapters
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type mySession struct {
+	ID         int64  `json:"id" db:"id"`
+	CreatedAt  string `json:"createdAt" db:"created_at"`
+	LastSeenAt string `json:"lastSeenAt" db:"last_seen_at"`
+	ExpiresAt  string `json:"expiresAt" db:"expires_at"`
+	UserAgent  string `json:"userAgent" db:"user_agent"`
+	IP         string `json:"ip" db:"ip"`
+	Current    bool   `json:"current" db:"-"`
+}
+
+// ListMySessions returns the caller's unexpired sessions (one per signed-in device).
+func (m *MeAPIService) ListMySessions(c echo.Context) error {
+	if m.db == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db not configured"})
+	}
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	q := m.db.Rebind(`
+		SELECT id,
+		       COALESCE(created_at::text,'') AS created_at,
+		       COALESCE(last_seen_at::text,'') AS last_seen_at,
+		       COALESCE(expires_at::text,'') AS expires_at,
+		       user_agent, ip
+		FROM sessions
+		WHERE user_id = ? AND expires_at > NOW()
+		ORDER BY last_seen_at DESC
+	`)
+	sessions := []mySession{}
+	if err := m.db.Select(&sessions, q, uid); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	current, _ := principalFrom(c)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current.SessionID
+	}
+	return c.JSON(http.StatusOK, map[string]any{"sessions": sessions})
+}
+
+// RevokeMySession signs out a single device.
+func (m *MeAPIService) RevokeMySession(c echo.Context) error {
+	if m.db == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db not configured"})
+	}
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	sid, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || sid <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid session id"})
+	}
+
+	q := m.db.Rebind(`DELETE FROM sessions WHERE id = ? AND user_id = ?`)
+	res, err := m.db.Exec(q, sid, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "session not found"})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+// RevokeMySessions signs out every other device. Pass ?includeCurrent=true
+// to end the calling session as well.
+func (m *MeAPIService) RevokeMySessions(c echo.Context) error {
+	if m.db == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db not configured"})
+	}
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	q := `DELETE FROM sessions WHERE user_id = ?`
+	args := []any{uid}
+	if current, ok := principalFrom(c); ok && c.QueryParam("includeCurrent") != "true" {
+		q += " AND id <> ?"
+		args = append(args, current.SessionID)
+	}
+
+	res, err := m.db.Exec(m.db.Rebind(q), args...)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	revoked, _ := res.RowsAffected()
+	return c.JSON(http.StatusOK, map[string]any{"ok": true, "revoked": revoked})
+}
@@ -0,0 +1,301 @@
+package adapters
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/labstack/echo/v4"
+)
+
+type lifecycleSub struct {
+	ID                string         `db:"id"`
+	Status            string         `db:"status"`
+	PlanID            string         `db:"plan_id"`
+	CarID             string         `db:"car_id"`
+	NextBillingDate   string         `db:"next_billing_date"`
+	PausedUntil       sql.NullString `db:"paused_until"`
+	CancelAtPeriodEnd bool           `db:"cancel_at_period_end"`
+}
+
+// mySubscription finds the member's subscription by id, or their only one
+// in one of statuses when id is empty. On failure it returns the status and
+// message to answer with.
+func (m *MeAPIService) mySubscription(uid int, id string, statuses ...string) (lifecycleSub, int, string) {
+	var subs []lifecycleSub
+	q := m.db.Rebind(`
+		SELECT id, status, plan_id, COALESCE(car_id, '') AS car_id, next_billing_date, paused_until, cancel_at_period_end
+		FROM subscriptions
+		WHERE user_id = ?
+		ORDER BY start_date DESC
+	`)
+	if err := m.db.Select(&subs, q, uid); err != nil {
+		return lifecycleSub{}, http.StatusInternalServerError, err.Error()
+	}
+	var match []lifecycleSub
+	for _, s := range subs {
+		if id != "" && s.ID == id {
+			if !slices.Contains(statuses, s.Status) {
+				return lifecycleSub{}, http.StatusConflict, "subscription is " + s.Status
+			}
+			return s, 0, ""
+		}
+		if id == "" && slices.Contains(statuses, s.Status) {
+			match = append(match, s)
+		}
+	}
+	switch {
+	case id != "" || len(match) == 0:
+		return lifecycleSub{}, http.StatusNotFound, "subscription not found"
+	case len(match) > 1:
+		return lifecycleSub{}, http.StatusBadRequest, "subscriptionId is required when you have more than one subscription"
+	}
+	return match[0], 0, ""
+}
+
+type cancelSubReq struct {
+	SubscriptionID string `json:"subscriptionId"`
+	// AtPeriodEnd keeps the subscription until its next billing date.
+	AtPeriodEnd bool   `json:"atPeriodEnd"`
+	ReasonCode  string `json:"reasonCode"`
+	Note        string `json:"note"`
+}
+
+// CancelMySubscription ends a subscription now or at the end of the period
+// already paid for. The reason is kept for churn reporting.
+func (m *MeAPIService) CancelMySubscription(c echo.Context) error {
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+	var req cancelSubReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+	}
+	req.ReasonCode = strings.TrimSpace(req.ReasonCode)
+	req.Note = strings.TrimSpace(req.Note)
+	if msg := checkReason(core.CancelReasons, req.ReasonCode, req.Note); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	statuses := []string{core.SubscriptionActive, core.SubscriptionPastDue, core.SubscriptionPaused}
+	if req.AtPeriodEnd {
+		statuses = []string{core.SubscriptionActive}
+	}
+	sub, status, msg := m.mySubscription(uid, strings.TrimSpace(req.SubscriptionID), statuses...)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer tx.Rollback()
+
+	ev := subscriptionEvent{SubscriptionID: sub.ID, UserID: uid, FromStatus: sub.Status, Detail: req.ReasonCode}
+	if req.AtPeriodEnd {
+		q := tx.Rebind(`
+			UPDATE subscriptions
+			SET cancel_at_period_end = TRUE, cancel_reason = ?, cancel_note = NULLIF(?, ''),
+			    pending_plan_id = NULL, pending_price_cents = NULL
+			WHERE id = ?
+		`)
+		if _, err := tx.Exec(q, req.ReasonCode, req.Note, sub.ID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		ev.Event, ev.ToStatus, ev.PeriodEnd = "cancel_scheduled", sub.Status, sub.NextBillingDate
+	} else {
+		q := tx.Rebind(`
+			UPDATE subscriptions
+			SET status = 'canceled', canceled_at = NOW(), cancel_reason = ?, cancel_note = NULLIF(?, ''),
+			    cancel_at_period_end = FALSE, pending_plan_id = NULL, pending_price_cents = NULL,
+			    paused_until = NULL
+			WHERE id = ?
+		`)
+		if _, err := tx.Exec(q, req.ReasonCode, req.Note, sub.ID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		ev.Event, ev.ToStatus = "canceled", core.SubscriptionCanceled
+		if err := endSubscriptionPeriod(tx, sub.ID, time.Now().UTC().Format("2006-01-02"), core.PeriodCanceled); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	if err := recordSubscriptionEvent(tx, ev); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return m.GetMySubscription(c)
+}
+
+// PauseMySubscription puts an active subscription on a vacation hold. The
+// next billing date moves back by the length of the pause, so paused weeks
+// aren't paid for.
+func (m *MeAPIService) PauseMySubscription(c echo.Context) error {
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+	var req struct {
+		SubscriptionID string `json:"subscriptionId"`
+		Weeks          int    `json:"weeks"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+	}
+	if req.Weeks < 1 || req.Weeks > core.MaxPauseWeeks {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("weeks must be between 1 and %d", core.MaxPauseWeeks)})
+	}
+	sub, status, msg := m.mySubscription(uid, strings.TrimSpace(req.SubscriptionID), core.SubscriptionActive)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	if sub.CancelAtPeriodEnd {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "subscription is already set to cancel"})
+	}
+
+	days := req.Weeks * 7
+	until := time.Now().UTC().AddDate(0, 0, days).Format("2006-01-02")
+	next := shiftDate(sub.NextBillingDate, days)
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer tx.Rollback()
+	q := tx.Rebind(`
+		UPDATE subscriptions
+		SET status = 'paused', paused_at = NOW(), paused_until = ?, next_billing_date = ?
+		WHERE id = ? AND status = 'active'
+	`)
+	if _, err := tx.Exec(q, until, next, sub.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := endSubscriptionPeriod(tx, sub.ID, time.Now().UTC().Format("2006-01-02"), core.PeriodPaused); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := recordSubscriptionEvent(tx, subscriptionEvent{
+		SubscriptionID: sub.ID,
+		UserID:         uid,
+		Event:          "paused",
+		FromStatus:     sub.Status,
+		ToStatus:       core.SubscriptionPaused,
+		Detail:         fmt.Sprintf("%d weeks", req.Weeks),
+		PeriodStart:    time.Now().UTC().Format("2006-01-02"),
+		PeriodEnd:      until,
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return m.GetMySubscription(c)
+}
+
+// ReactivateMySubscription undoes a scheduled cancellation, ends a pause
+// early, retries a past-due payment, or subscribes a canceled car again
+// on its last plan.
+func (m *MeAPIService) ReactivateMySubscription(c echo.Context) error {
+	uid, ok := m.authedUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+	var req struct {
+		SubscriptionID string `json:"subscriptionId"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+	}
+	id := strings.TrimSpace(req.SubscriptionID)
+	sub, status, msg := m.mySubscription(uid, id, core.SubscriptionPaused, core.SubscriptionPastDue, core.SubscriptionCanceled, core.SubscriptionActive)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	switch sub.Status {
+	case core.SubscriptionPastDue:
+		// Retry now rather than waiting for the engine's next attempt.
+		q := m.db.Rebind(`UPDATE subscriptions SET last_renewal_attempt_at = NULL WHERE id = ?`)
+		if _, err := m.db.Exec(q, sub.ID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		to, err := NewRenewalEngine(m.db, m.payments).renew(sub.ID, time.Now())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if to != core.SubscriptionActive {
+			return c.JSON(http.StatusPaymentRequired, map[string]string{"error": "Payment failed; update your payment method and try again."})
+		}
+		return m.GetMySubscription(c)
+
+	case core.SubscriptionCanceled:
+		return m.subscribe(c, uid, setSubReq{PlanID: sub.PlanID, CarID: sub.CarID})
+	}
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer tx.Rollback()
+
+	switch sub.Status {
+	case core.SubscriptionActive:
+		if !sub.CancelAtPeriodEnd {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "subscription is already active"})
+		}
+		q := tx.Rebind(`UPDATE subscriptions SET cancel_at_period_end = FALSE, cancel_reason = NULL, cancel_note = NULL WHERE id = ?`)
+		if _, err := tx.Exec(q, sub.ID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+
+	case core.SubscriptionPaused:
+		// Give back the paused days that weren't used.
+		left := 0
+		if until, err := time.Parse("2006-01-02", sub.PausedUntil.String); err == nil {
+			today, _ := time.Parse("2006-01-02", time.Now().UTC().Format("2006-01-02"))
+			left = max(0, int(until.Sub(today).Hours()/24))
+		}
+		q := tx.Rebind(`
+			UPDATE subscriptions
+			SET status = 'active', paused_at = NULL, paused_until = NULL, next_billing_date = ?
+			WHERE id = ? AND status = 'paused'
+		`)
+		if _, err := tx.Exec(q, shiftDate(sub.NextBillingDate, -left), sub.ID); err != nil {
+			if isUniqueViolation(err) {
+				return c.JSON(http.StatusConflict, map[string]string{"error": "This car already has a subscription."})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if err := startSubscriptionPeriod(tx, sub.ID, time.Now().UTC().Format("2006-01-02"), core.PeriodResumed); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	if err := recordSubscriptionEvent(tx, subscriptionEvent{
+		SubscriptionID: sub.ID,
+		UserID:         uid,
+		Event:          "reactivated",
+		FromStatus:     sub.Status,
+		ToStatus:       core.SubscriptionActive,
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return m.GetMySubscription(c)
+}
+
+// shiftDate moves a YYYY-MM-DD date by days, leaving it alone if unparsable.
+func shiftDate(date string, days int) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, days).Format("2006-01-02")
+}
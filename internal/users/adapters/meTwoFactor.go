@@ -0,0 +1,89 @@
+package adapters
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type twoFactorCodeReq struct {
+	Code string `json:"code"`
+}
+
+func twoFactorError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, errTwoFactorCode),
+		errors.Is(err, errTwoFactorEnabled),
+		errors.Is(err, errTwoFactorNotEnabled):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": "two-factor update failed"})
+}
+
+func (m *MeAPIService) GetMyTwoFactor(c echo.Context) error {
+	p, _ := principalFrom(c)
+	st, err := m.twoFactor.Status(p.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+// EnrollMyTwoFactor returns the secret, otpauth URI and QR to scan.
+// Nothing is enforced until ConfirmMyTwoFactor succeeds.
+func (m *MeAPIService) EnrollMyTwoFactor(c echo.Context) error {
+	p, _ := principalFrom(c)
+	enr, err := m.twoFactor.BeginEnrollment(p.UserID)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, enr)
+}
+
+func (m *MeAPIService) ConfirmMyTwoFactor(c echo.Context) error {
+	p, _ := principalFrom(c)
+	var req twoFactorCodeReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	codes, err := m.twoFactor.ConfirmEnrollment(p.UserID, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	m.twoFactor.audit(p.UserID, p.UserID, "2fa.enroll", map[string]any{})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true, "recoveryCodes": codes})
+}
+
+func (m *MeAPIService) RegenerateMyRecoveryCodes(c echo.Context) error {
+	p, _ := principalFrom(c)
+	var req twoFactorCodeReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	if _, err := m.twoFactor.Verify(p.UserID, req.Code); err != nil {
+		return twoFactorError(c, err)
+	}
+	codes, err := m.twoFactor.RegenerateRecoveryCodes(p.UserID)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	m.twoFactor.audit(p.UserID, p.UserID, "2fa.recovery_regenerate", map[string]any{})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true, "recoveryCodes": codes})
+}
+
+func (m *MeAPIService) DisableMyTwoFactor(c echo.Context) error {
+	p, _ := principalFrom(c)
+	var req twoFactorCodeReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	if _, err := m.twoFactor.Verify(p.UserID, req.Code); err != nil {
+		return twoFactorError(c, err)
+	}
+	if err := m.twoFactor.Disable(p.UserID); err != nil {
+		return twoFactorError(c, err)
+	}
+	m.twoFactor.audit(p.UserID, p.UserID, "2fa.disable", map[string]any{})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
@@ -0,0 +1,415 @@
+package adapters
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	offlineSnapshotVersion    = 3
+	defaultOfflineSnapshotTTL = 24 * time.Hour
+	// Offline decisions older than this are rejected on upload.
+	offlineMaxAge       = 7 * 24 * time.Hour
+	offlineMaxBatchSize = 500
+)
+
+// offlineSnapshotTTL is how long a scanner may decide from one snapshot (OFFLINE_SNAPSHOT_TTL_HOURS).
+func offlineSnapshotTTL() time.Duration {
+	if s := strings.TrimSpace(os.Getenv("OFFLINE_SNAPSHOT_TTL_HOURS")); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return time.Duration(n) * time.Hour
+		}
+	}
+	return defaultOfflineSnapshotTTL
+}
+
+// snapshotKey signs offline snapshots. SNAPSHOT_SIGNING_KEY is a base64
+// 32-byte Ed25519 seed; without it the seed is derived from the QR secret so
+// every instance signs with the same key.
+func snapshotKey(qr *qrSigner) ed25519.PrivateKey {
+	if s := strings.TrimSpace(os.Getenv("SNAPSHOT_SIGNING_KEY")); s != "" {
+		if seed, err := base64.StdEncoding.DecodeString(s); err == nil && len(seed) == ed25519.SeedSize {
+			return ed25519.NewKeyFromSeed(seed)
+		}
+	}
+	seed := sha256.Sum256(append([]byte("offline-snapshot:"), qr.secret...))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+// offlineQRKey lets a scanner check member codes without being able to
+// issue them: it only carries the public half of the QR signing key.
+type offlineQRKey struct {
+	Prefix             string `json:"prefix"`
+	Algorithm          string `json:"algorithm"`
+	PublicKey          string `json:"publicKey"`
+	TTLSeconds         int    `json:"ttlSeconds"`
+	AllowedSkewSeconds int    `json:"allowedSkewSeconds"`
+}
+
+// offlineMember is one active subscription. CarID and Plate are empty for
+// account-wide subscriptions, which cover all of the member's cars.
+type offlineMember struct {
+	UserID           int     `json:"userId" db:"user_id"`
+	CarID            string  `json:"carId,omitempty" db:"car_id"`
+	Plate            string  `json:"plate,omitempty" db:"plate"`
+	PlanID           string  `json:"planId" db:"plan_id"`
+	PeriodStart      string  `json:"periodStart" db:"period_start"`
+	WashesToday      int     `json:"washesToday" db:"today"`
+	WashesThisWeek   int     `json:"washesThisWeek" db:"this_week"`
+	WashesThisPeriod int     `json:"washesThisPeriod" db:"this_period"`
+	LastWashAt       *string `json:"lastWashAt" db:"last_wash_at"`
+}
+
+type offlineSnapshot struct {
+	Version         int                              `json:"version"`
+	DeviceID        string                           `json:"deviceId"`
+	LocationID      string                           `json:"locationId"`
+	IssuedAt        string                           `json:"issuedAt"`
+	ExpiresAt       string                           `json:"expiresAt"`
+	CooldownSeconds int                              `json:"cooldownSeconds"`
+	QR              offlineQRKey                     `json:"qr"`
+	Plans           map[string]core.PlanEntitlements `json:"plans"`
+	Members         []offlineMember                  `json:"members"`
+	// Packages is the catalog and PlanPackages what each plan includes, so
+	// the scanner can price the member upcharge offline.
+	Packages     []core.WashPackage  `json:"packages"`
+	PlanPackages map[string][]string `json:"planPackages"`
+}
+
+// Snapshot hands a registered device everything it needs to validate member
+// codes at its location while offline. Only devices (not browser sessions)
+// can fetch it, and only when QR_SIGNING_SECRET is set: the keys must not be
+// derived from the session secret.
+func (s *ScanAPIService) Snapshot(c echo.Context) error {
+	d, ok := deviceFrom(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "device credentials required"})
+	}
+	if !s.qr.dedicated {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "offline snapshots need a dedicated QR_SIGNING_SECRET"})
+	}
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := dayStart.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+
+	// Period start matches billingPeriodStart.
+	var rows []struct {
+		offlineMember
+		EntitlementsJSON string `db:"entitlements_json"`
+	}
+	q := s.db.Rebind(`
+		SELECT s.user_id, COALESCE(s.car_id, '') AS car_id, COALESCE(c.plate, '') AS plate, s.plan_id, p.entitlements_json,
+		       GREATEST(s.next_billing_date::date - INTERVAL '1 month', s.start_date::date)::date::text AS period_start,
+		       COUNT(w.id) FILTER (WHERE w.scanned_at >= ?) AS today,
+		       COUNT(w.id) FILTER (WHERE w.scanned_at >= ?) AS this_week,
+		       COUNT(w.id) FILTER (WHERE w.scanned_at >= GREATEST(s.next_billing_date::date - INTERVAL '1 month', s.start_date::date)) AS this_period,
+		       MAX(w.scanned_at)::text AS last_wash_at
+		FROM subscriptions s
+		JOIN plans p ON p.id = s.plan_id
+		LEFT JOIN cars c ON c.id = s.car_id
+		LEFT JOIN wash_events w ON w.user_id = s.user_id AND w.result IN ('allowed', 'allowed_override')
+		                       AND (s.car_id IS NULL OR w.car_id = s.car_id)
+		WHERE s.status = 'active'
+		GROUP BY s.id, s.user_id, s.car_id, c.plate, s.plan_id, p.entitlements_json, s.start_date, s.next_billing_date
+		ORDER BY s.user_id, s.car_id
+	`)
+	if err := s.db.Select(&rows, q, dayStart, weekStart); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	catalog, err := loadPackageCatalog(s.db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	planPackages, err := loadPlanPackages(s.db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	snap := offlineSnapshot{
+		Version:         offlineSnapshotVersion,
+		DeviceID:        d.ID,
+		LocationID:      d.LocationID,
+		IssuedAt:        now.Format(time.RFC3339),
+		ExpiresAt:       now.Add(offlineSnapshotTTL()).Format(time.RFC3339),
+		CooldownSeconds: int(scanCooldown() / time.Second),
+		QR: offlineQRKey{
+			Prefix:             qrPrefix,
+			Algorithm:          "ed25519",
+			PublicKey:          base64.StdEncoding.EncodeToString(s.qr.publicKey()),
+			TTLSeconds:         int(s.qr.ttl / time.Second),
+			AllowedSkewSeconds: int(qrAllowedSkew / time.Second),
+		},
+		Plans:        map[string]core.PlanEntitlements{},
+		Members:      []offlineMember{},
+		Packages:     catalog,
+		PlanPackages: map[string][]string{},
+	}
+	for _, r := range rows {
+		ent := parseEntitlements(r.EntitlementsJSON)
+		// Members whose plan doesn't cover this site would be denied anyway.
+		if len(ent.LocationIDs) > 0 && !slices.Contains(ent.LocationIDs, d.LocationID) {
+			continue
+		}
+		snap.Plans[r.PlanID] = ent
+		snap.PlanPackages[r.PlanID] = planPackages[r.PlanID]
+		snap.Members = append(snap.Members, r.offlineMember)
+	}
+
+	raw, err := json.Marshal(snap)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	key := snapshotKey(s.qr)
+	pub := key.Public().(ed25519.PublicKey)
+	keyID := sha256.Sum256(pub)
+	return c.JSON(http.StatusOK, map[string]any{
+		"snapshot":  json.RawMessage(raw),
+		"signature": base64.StdEncoding.EncodeToString(ed25519.Sign(key, raw)),
+		"keyId":     hex.EncodeToString(keyID[:8]),
+		"publicKey": base64.StdEncoding.EncodeToString(pub),
+	})
+}
+
+type offlineScanDecision struct {
+	// ClientID is generated by the scanner and makes re-uploads harmless.
+	ClientID  string `json:"clientId"`
+	QR        string `json:"qr"`
+	CarID     string `json:"carId"`
+	PackageID string `json:"packageId"`
+	ScannedAt string `json:"scannedAt"`
+	Allowed   bool   `json:"allowed"`
+	Reason    string `json:"reason"`
+	Lane      string `json:"lane"`
+}
+
+type offlineBatchReq struct {
+	LocationID string                `json:"locationId"`
+	Decisions  []offlineScanDecision `json:"decisions"`
+}
+
+// Per-decision outcome of a batch upload.
+const (
+	offlineRecorded  = "recorded"
+	offlineConflict  = "conflict"
+	offlineDuplicate = "duplicate"
+	offlineRejected  = "rejected"
+)
+
+type offlineScanResult struct {
+	ClientID     string `json:"clientId"`
+	Status       string `json:"status"`
+	UserID       int    `json:"userId,omitempty"`
+	ServerReason string `json:"serverReason,omitempty"`
+}
+
+// ScanBatch records decisions a scanner made offline. Each is re-checked
+// against the server's view at its original time. The scanner's decision is
+// what happened at the lane, so it is what gets recorded; disagreements come
+// back as conflicts.
+func (s *ScanAPIService) ScanBatch(c echo.Context) error {
+	var req offlineBatchReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	if len(req.Decisions) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "decisions are required"})
+	}
+	if len(req.Decisions) > offlineMaxBatchSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "too many decisions in one batch"})
+	}
+
+	locationID := strings.TrimSpace(req.LocationID)
+	if d, ok := deviceFrom(c); ok {
+		locationID = d.LocationID
+	} else if p, _ := principalFrom(c); !p.CanAccessLocation(locationID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not assigned to this location"})
+	}
+	scope := scanRequestScope(c)
+
+	// Replay in scan order so limits accumulate the way they did at the lane.
+	type pending struct {
+		offlineScanDecision
+		at    time.Time
+		index int
+	}
+	results := make([]offlineScanResult, len(req.Decisions))
+	var queue []pending
+	now := time.Now().UTC()
+	for i, d := range req.Decisions {
+		d.ClientID = strings.TrimSpace(d.ClientID)
+		d.QR = strings.TrimSpace(d.QR)
+		d.PackageID = strings.TrimSpace(d.PackageID)
+		d.CarID = strings.TrimSpace(d.CarID)
+		results[i] = offlineScanResult{ClientID: d.ClientID, Status: offlineRejected}
+
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(d.ScannedAt))
+		switch {
+		case d.ClientID == "" || len(d.ClientID) > 128:
+			results[i].ServerReason = "clientId is required (max 128 chars)"
+		case err != nil:
+			results[i].ServerReason = "scannedAt must be RFC 3339"
+		case at.After(now.Add(qrAllowedSkew)):
+			results[i].ServerReason = "scannedAt is in the future"
+		case now.Sub(at) > offlineMaxAge:
+			results[i].ServerReason = "scannedAt is too old"
+		default:
+			queue = append(queue, pending{offlineScanDecision: d, at: at.UTC(), index: i})
+		}
+	}
+	slices.SortStableFunc(queue, func(a, b pending) int { return a.at.Compare(b.at) })
+
+	summary := map[string]int{}
+	for _, p := range queue {
+		res, err := s.reconcileOffline(scanSourceFrom(c, p.Lane), scope+":"+p.ClientID, locationID, p.offlineScanDecision, p.at)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]any{"error": "Failed to record wash event", "results": results})
+		}
+		res.ClientID = p.ClientID
+		results[p.index] = res
+	}
+	for _, r := range results {
+		summary[r.Status]++
+	}
+	return c.JSON(http.StatusOK, map[string]any{"results": results, "summary": summary})
+}
+
+func (s *ScanAPIService) reconcileOffline(src scanSource, clientEventID, locationID string, d offlineScanDecision, at time.Time) (offlineScanResult, error) {
+	var exists int
+	if err := s.db.Get(&exists, s.db.Rebind(`SELECT 1 FROM wash_events WHERE client_event_id = ? LIMIT 1`), clientEventID); err == nil {
+		return offlineScanResult{Status: offlineDuplicate}, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return offlineScanResult{}, err
+	}
+
+	claims, serverReason := s.qr.Verify(d.QR, at)
+	userID := claims.UserID
+	if userID == 0 {
+		// Not a code this server issued; don't attribute it to anyone.
+		ev := newOfflineWashEvent(src, clientEventID, 0, locationID, "denied", d.QR, "Offline: "+serverReason, at)
+		inserted, err := writeWashEvent(s.db, ev)
+		if err != nil {
+			return offlineScanResult{}, err
+		}
+		if inserted {
+			washFeed.publish(ev)
+		}
+		return offlineScanResult{Status: offlineRejected, ServerReason: serverReason}, nil
+	}
+	if serverReason == "" {
+		fresh, err := consumeQRNonce(s.db, claims)
+		if err != nil {
+			return offlineScanResult{}, err
+		}
+		if !fresh {
+			serverReason = "QR code already used"
+		}
+	}
+
+	var sub subscriptionRow
+	carID := ""
+	if serverReason == "" {
+		vehicle, candidates, reason, err := qrVehicle(s.db, userID, ScanRequest{CarID: d.CarID})
+		switch {
+		case err != nil:
+			return offlineScanResult{}, err
+		case reason != "":
+			serverReason = reason
+		case len(candidates) > 0:
+			serverReason = "Vehicle not specified"
+		case vehicle != nil:
+			carID = vehicle.CarID
+		}
+	}
+	if serverReason == "" {
+		var err error
+		if sub, err = coveringSubscription(s.db, userID, carID); err != nil {
+			serverReason = uncoveredReason(s.db, userID, carID)
+		}
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return offlineScanResult{}, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(tx.Rebind(`SELECT pg_advisory_xact_lock(?, ?)`), scanLockNamespace, userID); err != nil {
+		return offlineScanResult{}, err
+	}
+
+	if serverReason == "" {
+		usage, err := loadWashUsage(tx, userID, sub.CarID, billingPeriodStart(sub.StartDate, sub.NextBillingDate, at), at)
+		if err != nil {
+			return offlineScanResult{}, err
+		}
+		if cooldown := scanCooldown(); cooldown > 0 && usage.LastWashAt != nil && at.Sub(*usage.LastWashAt) < cooldown {
+			serverReason = "Duplicate scan within cooldown"
+		} else {
+			serverReason = loadPlanEntitlements(s.db, sub.PlanID).Check(usage, locationID, at)
+		}
+	}
+
+	// Price the package the scanner sold, as the online scan would have.
+	var pkg *core.PackageOffer
+	if serverReason == "" {
+		offers, err := packageOffers(s.db, sub.PlanID)
+		if err != nil {
+			return offlineScanResult{}, err
+		}
+		pkg, serverReason = core.ChoosePackage(offers, d.PackageID)
+	}
+
+	res := offlineScanResult{Status: offlineRecorded, UserID: userID, ServerReason: serverReason}
+	result, reason := "denied", strings.TrimSpace(d.Reason)
+	if d.Allowed {
+		result, reason = "allowed", ""
+		if serverReason != "" {
+			res.Status = offlineConflict
+			reason = "Offline conflict: " + serverReason
+		}
+	} else if reason == "" {
+		reason = serverReason
+	}
+
+	ev := newOfflineWashEvent(src, clientEventID, userID, locationID, result, d.QR, reason, at)
+	ev.CarID = carID
+	if result == "allowed" && pkg != nil {
+		ev.PackageID, ev.UpchargeCents = pkg.PackageID, pkg.UpchargeCents
+	}
+	inserted, err := writeWashEvent(tx, ev)
+	if err != nil {
+		return offlineScanResult{}, err
+	}
+	if !inserted {
+		return offlineScanResult{Status: offlineDuplicate}, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return offlineScanResult{}, err
+	}
+	washFeed.publish(ev)
+	return res, nil
+}
+
+// newOfflineWashEvent is an uploaded decision, kept at its original time.
+func newOfflineWashEvent(src scanSource, clientEventID string, userID int, locationID, result, rawQR, reason string, scannedAt time.Time) WashEvent {
+	ev := newWashEvent(src, userID, locationID, result, rawQR, reason)
+	ev.ScannedAt = scannedAt.UTC()
+	ev.Offline = true
+	ev.clientEventID = clientEventID
+	return ev
+}
@@ -0,0 +1,63 @@
+package adapters
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher implements ports.HashingPasswords. It also accepts the
+// legacy unsalted SHA-256 hex digests and plaintext seed passwords so those
+// accounts can sign in once and be upgraded.
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{cost: bcrypt.DefaultCost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (h *BcryptHasher) Verify(hash, password string) (bool, bool) {
+	if isBcryptHash(hash) {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			return false, false
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		return true, err != nil || cost < h.cost
+	}
+
+	if isLegacySHA256Hash(hash) {
+		sum := sha256.Sum256([]byte(password))
+		legacy := hex.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(legacy), []byte(strings.ToLower(hash))) == 1, true
+	}
+
+	// Plaintext (demo seeds created before hashing was enforced)
+	if hash == "" {
+		return false, false
+	}
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1, true
+}
+
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
+
+func isLegacySHA256Hash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
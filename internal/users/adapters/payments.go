@@ -0,0 +1,113 @@
+package adapters
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/edlingao/hexago/internal/users/ports"
+	"github.com/google/uuid"
+)
+
+// webhookTolerance is how far a webhook's signed timestamp may be from now.
+const webhookTolerance = 5 * time.Minute
+
+// NewPaymentsFromEnv picks the payment adapter from PAYMENTS_DRIVER:
+// "stripe" uses STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET, "fake" is the
+// in-process provider for local testing, and anything else approves every
+// charge without moving money.
+func NewPaymentsFromEnv() ports.TakingPayments {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("PAYMENTS_DRIVER"))) {
+	case "stripe":
+		return NewStripePayments(os.Getenv("STRIPE_SECRET_KEY"), os.Getenv("STRIPE_WEBHOOK_SECRET"))
+	case "fake":
+		secret := os.Getenv("PAYMENTS_WEBHOOK_SECRET")
+		if secret == "" {
+			secret = "whsec_fake"
+		}
+		return NewFakePayments(secret)
+	}
+	return &ManualPayments{}
+}
+
+// ManualPayments implements ports.TakingPayments by logging each charge and
+// approving it, for sites that collect payment outside the app.
+type ManualPayments struct{}
+
+func (p *ManualPayments) CreateCustomer(customer core.PaymentCustomer) (string, error) {
+	return "manual-" + strconv.Itoa(customer.UserID), nil
+}
+
+func (p *ManualPayments) AttachPaymentMethod(customerID, token string) (core.PaymentMethod, error) {
+	last4 := token
+	if len(last4) > 4 {
+		last4 = last4[len(last4)-4:]
+	}
+	return core.PaymentMethod{ID: "manual-" + uuid.NewString(), Brand: "manual", Last4: last4}, nil
+}
+
+func (p *ManualPayments) Charge(req core.ChargeRequest) (core.Charge, error) {
+	log.Printf("payments: manual charge of %d %s to user %d (%s)", req.AmountCents, req.Currency, req.UserID, req.Description)
+	return core.Charge{ID: "manual-" + req.IdempotencyKey, AmountCents: req.AmountCents, Status: core.ChargeSucceeded}, nil
+}
+
+func (p *ManualPayments) Refund(chargeID string, amountCents int, idempotencyKey string) (core.Refund, error) {
+	log.Printf("payments: manual refund of %d on %s", amountCents, chargeID)
+	return core.Refund{ID: "manual-" + idempotencyKey, ChargeID: chargeID, AmountCents: amountCents}, nil
+}
+
+func (p *ManualPayments) ParseWebhook(payload []byte, signature string) (core.PaymentEvent, error) {
+	return core.PaymentEvent{}, errors.New("payments: manual driver has no webhooks")
+}
+
+// signWebhook produces a Stripe-style signature header, "t=<unix>,v1=<hex hmac>",
+// over "<unix>.<payload>".
+func signWebhook(payload []byte, secret string, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(payload)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyWebhookSignature checks a signWebhook header. Any of several v1
+// values may match, as during a secret rotation.
+func verifyWebhookSignature(payload []byte, header, secret string, now time.Time) error {
+	if secret == "" {
+		return errors.New("webhook secret not configured")
+	}
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return errors.New("malformed webhook signature")
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > webhookTolerance || d < -webhookTolerance {
+		return fmt.Errorf("webhook timestamp outside tolerance")
+	}
+	want := signWebhook(payload, secret, time.Unix(sec, 0))
+	_, wantSig, _ := strings.Cut(want, ",v1=")
+	for _, s := range sigs {
+		if hmac.Equal([]byte(s), []byte(wantSig)) {
+			return nil
+		}
+	}
+	return errors.New("webhook signature mismatch")
+}
@@ -0,0 +1,198 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/google/uuid"
+)
+
+type fakeCard struct {
+	brand   string
+	last4   string
+	decline string // decline code; empty charges fine
+	message string
+}
+
+// fakeCards are the tokens FakePayments accepts, named after Stripe's test
+// payment methods.
+var fakeCards = map[string]fakeCard{
+	"pm_card_visa":              {brand: "visa", last4: "4242"},
+	"pm_card_mastercard":        {brand: "mastercard", last4: "4444"},
+	"pm_card_chargeDeclined":    {brand: "visa", last4: "0002", decline: "card_declined", message: "Your card was declined."},
+	"pm_card_insufficientFunds": {brand: "visa", last4: "9995", decline: "insufficient_funds", message: "Your card has insufficient funds."},
+	"pm_card_expired":           {brand: "visa", last4: "0069", decline: "expired_card", message: "Your card has expired."},
+}
+
+type fakeCharge struct {
+	charge   core.Charge
+	refunded int
+}
+
+// FakePayments implements ports.TakingPayments in memory so subscribing,
+// renewing and failing can be exercised without a provider. Declines come
+// from the test card used or DeclineNext, and every charge and refund is
+// delivered as a signed webhook to the sink, as a real provider would.
+type FakePayments struct {
+	mu            sync.Mutex
+	webhookSecret string
+	customers     map[string]core.PaymentCustomer
+	methods       map[string]fakeCard
+	charges       map[string]*fakeCharge
+	byKey         map[string]string
+	declineNext   int
+	sink          func(payload []byte, signature string)
+}
+
+func NewFakePayments(webhookSecret string) *FakePayments {
+	return &FakePayments{
+		webhookSecret: webhookSecret,
+		customers:     map[string]core.PaymentCustomer{},
+		methods:       map[string]fakeCard{},
+		charges:       map[string]*fakeCharge{},
+		byKey:         map[string]string{},
+	}
+}
+
+// SetWebhookSink sets where webhook events are delivered. Without a sink
+// they are only logged.
+func (p *FakePayments) SetWebhookSink(sink func(payload []byte, signature string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sink = sink
+}
+
+// DeclineNext makes the next n charges decline whatever the card.
+func (p *FakePayments) DeclineNext(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.declineNext = n
+}
+
+// Fail reverses a charge that went through, as a late bank failure would,
+// and delivers the charge.failed webhook.
+func (p *FakePayments) Fail(chargeID, reason string) error {
+	p.mu.Lock()
+	ch, ok := p.charges[chargeID]
+	if ok {
+		ch.charge.Status = core.ChargeFailed
+	}
+	p.mu.Unlock()
+	if !ok {
+		return errors.New("fake payments: no such charge")
+	}
+	p.emit(core.PaymentEvent{Type: core.PaymentEventFailed, ChargeID: chargeID, AmountCents: ch.charge.AmountCents, FailureReason: reason})
+	return nil
+}
+
+func (p *FakePayments) CreateCustomer(customer core.PaymentCustomer) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := "cus_fake_" + strconv.Itoa(customer.UserID)
+	p.customers[id] = customer
+	return id, nil
+}
+
+func (p *FakePayments) AttachPaymentMethod(customerID, token string) (core.PaymentMethod, error) {
+	card, ok := fakeCards[token]
+	if !ok {
+		return core.PaymentMethod{}, &core.DeclineError{Code: "invalid_token", Message: "Unknown test card " + token}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := "pm_fake_" + uuid.NewString()[:12]
+	p.methods[id] = card
+	exp := time.Now().AddDate(3, 0, 0)
+	return core.PaymentMethod{ID: id, Brand: card.brand, Last4: card.last4, ExpMonth: int(exp.Month()), ExpYear: exp.Year()}, nil
+}
+
+func (p *FakePayments) Charge(req core.ChargeRequest) (core.Charge, error) {
+	p.mu.Lock()
+	if id, ok := p.byKey[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		ch := p.charges[id].charge
+		p.mu.Unlock()
+		return ch, nil
+	}
+	card, ok := p.methods[req.PaymentMethodID]
+	var decline *core.DeclineError
+	switch {
+	case !ok:
+		decline = &core.DeclineError{Code: "no_payment_method", Message: "No payment method on file"}
+	case p.declineNext > 0:
+		p.declineNext--
+		decline = &core.DeclineError{Code: "card_declined", Message: "Your card was declined."}
+	case card.decline != "":
+		decline = &core.DeclineError{Code: card.decline, Message: card.message}
+	}
+	id := "pi_fake_" + uuid.NewString()[:12]
+	ch := core.Charge{ID: id, AmountCents: req.AmountCents, Status: core.ChargeSucceeded}
+	if decline != nil {
+		ch.Status = core.ChargeFailed
+	}
+	p.charges[id] = &fakeCharge{charge: ch}
+	if req.IdempotencyKey != "" {
+		p.byKey[req.IdempotencyKey] = id
+	}
+	p.mu.Unlock()
+
+	if decline != nil {
+		p.emit(core.PaymentEvent{Type: core.PaymentEventFailed, ChargeID: id, AmountCents: req.AmountCents, FailureReason: decline.Message})
+		return core.Charge{}, decline
+	}
+	p.emit(core.PaymentEvent{Type: core.PaymentEventSucceeded, ChargeID: id, AmountCents: req.AmountCents})
+	return ch, nil
+}
+
+func (p *FakePayments) Refund(chargeID string, amountCents int, idempotencyKey string) (core.Refund, error) {
+	p.mu.Lock()
+	ch, ok := p.charges[chargeID]
+	if !ok || ch.charge.Status != core.ChargeSucceeded {
+		p.mu.Unlock()
+		return core.Refund{}, errors.New("fake payments: charge can't be refunded")
+	}
+	if ch.refunded+amountCents > ch.charge.AmountCents {
+		p.mu.Unlock()
+		return core.Refund{}, errors.New("fake payments: refund exceeds charge")
+	}
+	ch.refunded += amountCents
+	total := ch.refunded
+	p.mu.Unlock()
+
+	p.emit(core.PaymentEvent{Type: core.PaymentEventRefunded, ChargeID: chargeID, AmountCents: total})
+	return core.Refund{ID: "re_fake_" + uuid.NewString()[:12], ChargeID: chargeID, AmountCents: amountCents}, nil
+}
+
+func (p *FakePayments) ParseWebhook(payload []byte, signature string) (core.PaymentEvent, error) {
+	if err := verifyWebhookSignature(payload, signature, p.webhookSecret, time.Now()); err != nil {
+		return core.PaymentEvent{}, err
+	}
+	var ev core.PaymentEvent
+	err := json.Unmarshal(payload, &ev)
+	return ev, err
+}
+
+// emit delivers ev to the sink in the background, after the call that
+// caused it has returned.
+func (p *FakePayments) emit(ev core.PaymentEvent) {
+	ev.ID = "evt_fake_" + uuid.NewString()[:12]
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	p.mu.Lock()
+	sink := p.sink
+	p.mu.Unlock()
+	if sink == nil {
+		log.Printf("payments: fake webhook %s for %s (no sink)", ev.Type, ev.ChargeID)
+		return
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		sink(payload, signWebhook(payload, p.webhookSecret, time.Now()))
+	}()
+}
@@ -0,0 +1,316 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/edlingao/hexago/internal/users/ports"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// PaymentRow is one charge attempt in the payments ledger.
+type PaymentRow struct {
+	ID               string    `json:"id" db:"id"`
+	UserID           int       `json:"userId" db:"user_id"`
+	SubscriptionID   string    `json:"subscriptionId,omitempty" db:"subscription_id"`
+	ProviderChargeID string    `json:"providerChargeId,omitempty" db:"provider_charge_id"`
+	AmountCents      int       `json:"amountCents" db:"amount_cents"`
+	RefundedCents    int       `json:"refundedCents" db:"refunded_cents"`
+	Currency         string    `json:"currency" db:"currency"`
+	Status           string    `json:"status" db:"status"`
+	FailureReason    string    `json:"failureReason,omitempty" db:"failure_reason"`
+	Description      string    `json:"description" db:"description"`
+	CreatedAt        time.Time `json:"createdAt" db:"created_at"`
+}
+
+const paymentSelect = `
+	SELECT id, user_id, COALESCE(subscription_id, '') AS subscription_id,
+	       COALESCE(provider_charge_id, '') AS provider_charge_id, amount_cents, refunded_cents,
+	       currency, status, failure_reason, description, created_at
+	FROM payments`
+
+// paymentCustomer returns the member's provider customer, creating it on first use.
+func paymentCustomer(db *sqlx.DB, payments ports.TakingPayments, userID int) (customerID, email string, err error) {
+	var u struct {
+		CustomerID sql.NullString `db:"payment_customer_id"`
+		Email      string         `db:"email"`
+		Name       string         `db:"name"`
+	}
+	q := db.Rebind(`
+		SELECT payment_customer_id, COALESCE(email, '') AS email,
+		       TRIM(COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) AS name
+		FROM users WHERE id = ?
+	`)
+	if err := db.Get(&u, q, userID); err != nil {
+		return "", "", err
+	}
+	if u.CustomerID.Valid && u.CustomerID.String != "" {
+		return u.CustomerID.String, u.Email, nil
+	}
+	id, err := payments.CreateCustomer(core.PaymentCustomer{UserID: userID, Email: u.Email, Name: u.Name})
+	if err != nil {
+		return "", "", err
+	}
+	q = db.Rebind(`UPDATE users SET payment_customer_id = ? WHERE id = ? AND payment_customer_id IS NULL`)
+	if _, err := db.Exec(q, id, userID); err != nil {
+		return "", "", err
+	}
+	return id, u.Email, nil
+}
+
+func defaultPaymentMethodID(db *sqlx.DB, userID int) string {
+	var id string
+	_ = db.Get(&id, db.Rebind(`SELECT id FROM payment_methods WHERE user_id = ? AND is_default`), userID)
+	return id
+}
+
+type memberCharge struct {
+	UserID         int
+	SubscriptionID string
+	AmountCents    int
+	Description    string
+	IdempotencyKey string
+}
+
+// chargeMember charges the member's default card and records the attempt,
+// declined or not, in payments. The error is the charge's, or the ledger's
+// when a charge that went through couldn't be recorded; that charge is
+// given back, since nothing could find it to settle or refund it later.
+func chargeMember(db *sqlx.DB, payments ports.TakingPayments, mc memberCharge) (PaymentRow, error) {
+	customerID, email, err := paymentCustomer(db, payments, mc.UserID)
+	if err != nil {
+		return PaymentRow{}, err
+	}
+	ch, chargeErr := payments.Charge(core.ChargeRequest{
+		UserID:          mc.UserID,
+		Email:           email,
+		CustomerID:      customerID,
+		PaymentMethodID: defaultPaymentMethodID(db, mc.UserID),
+		AmountCents:     mc.AmountCents,
+		Currency:        billingCurrency,
+		Description:     mc.Description,
+		IdempotencyKey:  mc.IdempotencyKey,
+	})
+	row := PaymentRow{
+		ID:               uuid.NewString(),
+		UserID:           mc.UserID,
+		SubscriptionID:   mc.SubscriptionID,
+		ProviderChargeID: ch.ID,
+		AmountCents:      mc.AmountCents,
+		Currency:         billingCurrency,
+		Status:           ch.Status,
+		Description:      mc.Description,
+		CreatedAt:        time.Now().UTC(),
+	}
+	if chargeErr != nil {
+		row.Status, row.FailureReason = core.ChargeFailed, chargeErr.Error()
+	}
+
+	// A retried idempotency key returns a charge that is already recorded.
+	q := db.Rebind(`
+		INSERT INTO payments (id, user_id, subscription_id, provider_charge_id, amount_cents, currency,
+		                      status, failure_reason, description, created_at, updated_at)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (provider_charge_id) DO NOTHING
+	`)
+	if _, err := db.Exec(q, row.ID, row.UserID, row.SubscriptionID, row.ProviderChargeID, row.AmountCents, row.Currency,
+		row.Status, row.FailureReason, row.Description, row.CreatedAt, row.CreatedAt); err != nil {
+		log.Printf("payments: recording charge %s: %v", row.ProviderChargeID, err)
+		if chargeErr == nil && row.ProviderChargeID != "" {
+			if _, rerr := payments.Refund(row.ProviderChargeID, row.AmountCents, "refund-"+row.ID+"-0"); rerr != nil {
+				log.Printf("payments: refunding unrecorded charge %s: %v", row.ProviderChargeID, rerr)
+			}
+			return PaymentRow{}, fmt.Errorf("recording payment: %w", err)
+		}
+	}
+	return row, chargeErr
+}
+
+// refundCharge gives back all of a charge whose purchase could not be saved,
+// so the member isn't left paying for nothing. A charge some saved change
+// already cites in its history paid for that change and is kept: a retry
+// with the same idempotency key gets the same charge back. Failures are
+// logged; there is no one left to return them to.
+func refundCharge(db *sqlx.DB, payments ports.TakingPayments, p PaymentRow) {
+	if p.ProviderChargeID == "" || p.Status == core.ChargeFailed {
+		return
+	}
+	var owned bool
+	q := db.Rebind(`SELECT EXISTS (SELECT 1 FROM subscription_history WHERE charge_id = ?)`)
+	if err := db.Get(&owned, q, p.ProviderChargeID); err != nil || owned {
+		if err != nil {
+			log.Printf("payments: checking charge %s before refunding: %v", p.ProviderChargeID, err)
+		}
+		return
+	}
+	if _, err := payments.Refund(p.ProviderChargeID, p.AmountCents, "refund-"+p.ID+"-0"); err != nil {
+		log.Printf("payments: refunding charge %s: %v", p.ProviderChargeID, err)
+		return
+	}
+	q = db.Rebind(`
+		UPDATE payments
+		SET refunded_cents = amount_cents, status = 'refunded', updated_at = NOW()
+		WHERE provider_charge_id = ?
+	`)
+	if _, err := db.Exec(q, p.ProviderChargeID); err != nil {
+		log.Printf("payments: recording refund of charge %s: %v", p.ProviderChargeID, err)
+	}
+}
+
+// lockMemberBilling takes the member's billing lock on tx, so their
+// purchases run one at a time and can't share a charge. It is held until
+// tx ends.
+func lockMemberBilling(tx *sqlx.Tx, userID int) error {
+	_, err := tx.Exec(tx.Rebind(`SELECT pg_advisory_xact_lock(?, ?)`), billingLockNamespace, userID)
+	return err
+}
+
+// applyPaymentEvent settles the ledger from a webhook. A charge that fails
+// after its subscription was renewed puts the subscription past_due.
+func applyPaymentEvent(db *sqlx.DB, ev core.PaymentEvent) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(tx.Rebind(`INSERT INTO payment_webhook_events (id, type) VALUES (?, ?) ON CONFLICT DO NOTHING`), ev.ID, ev.Type)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	switch ev.Type {
+	case core.PaymentEventSucceeded:
+		q := tx.Rebind(`UPDATE payments SET status = 'succeeded', updated_at = NOW() WHERE provider_charge_id = ? AND status = 'pending'`)
+		if _, err := tx.Exec(q, ev.ChargeID); err != nil {
+			return err
+		}
+
+	case core.PaymentEventFailed:
+		var p struct {
+			ID             string         `db:"id"`
+			UserID         int            `db:"user_id"`
+			SubscriptionID sql.NullString `db:"subscription_id"`
+			AmountCents    int            `db:"amount_cents"`
+			Status         string         `db:"status"`
+		}
+		q := tx.Rebind(`SELECT id, user_id, subscription_id, amount_cents, status FROM payments WHERE provider_charge_id = ? FOR UPDATE`)
+		if err := tx.Get(&p, q, ev.ChargeID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			return err
+		}
+		if p.Status == core.ChargeFailed {
+			break
+		}
+		q = tx.Rebind(`UPDATE payments SET status = 'failed', failure_reason = ?, updated_at = NOW() WHERE id = ?`)
+		if _, err := tx.Exec(q, ev.FailureReason, p.ID); err != nil {
+			return err
+		}
+		if !p.SubscriptionID.Valid {
+			break
+		}
+		q = tx.Rebind(`
+			UPDATE subscriptions
+			SET status = 'past_due', past_due_since = COALESCE(past_due_since, NOW()), last_renewal_attempt_at = NOW()
+			WHERE id = ? AND status = 'active'
+		`)
+		res, err := tx.Exec(q, p.SubscriptionID.String)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			if err := recordSubscriptionEvent(tx, subscriptionEvent{
+				SubscriptionID: p.SubscriptionID.String,
+				UserID:         p.UserID,
+				Event:          "payment_failed",
+				FromStatus:     core.SubscriptionActive,
+				ToStatus:       core.SubscriptionPastDue,
+				AmountCents:    &p.AmountCents,
+				ChargeID:       ev.ChargeID,
+				Detail:         ev.FailureReason,
+			}); err != nil {
+				return err
+			}
+		}
+
+	case core.PaymentEventRefunded:
+		q := tx.Rebind(`
+			UPDATE payments
+			SET refunded_cents = GREATEST(refunded_cents, ?),
+			    status = CASE WHEN GREATEST(refunded_cents, ?) >= amount_cents THEN 'refunded' ELSE status END,
+			    updated_at = NOW()
+			WHERE provider_charge_id = ?
+		`)
+		if _, err := tx.Exec(q, ev.AmountCents, ev.AmountCents, ev.ChargeID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PaymentsWebhookService receives the provider's webhooks.
+type PaymentsWebhookService struct {
+	httpService *echo.Group
+	db          *sqlx.DB
+	payments    ports.TakingPayments
+}
+
+// NewPaymentsWebhookService also subscribes to the fake provider's
+// deliveries, which never go over HTTP.
+func NewPaymentsWebhookService(httpService *echo.Group, db *sqlx.DB, payments ports.TakingPayments) *PaymentsWebhookService {
+	s := &PaymentsWebhookService{httpService: httpService, db: db, payments: payments}
+	if fake, ok := payments.(*FakePayments); ok {
+		fake.SetWebhookSink(func(payload []byte, signature string) {
+			if _, err := s.receive(payload, signature); err != nil {
+				log.Println("payments: fake webhook:", err)
+			}
+		})
+	}
+	return s
+}
+
+func (s *PaymentsWebhookService) RegisterRoutes() {
+	s.httpService.POST("/payments/webhook", s.Webhook)
+}
+
+// receive verifies and applies one delivery, returning the status to answer with.
+func (s *PaymentsWebhookService) receive(payload []byte, signature string) (int, error) {
+	ev, err := s.payments.ParseWebhook(payload, signature)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if ev.ID == "" {
+		return http.StatusBadRequest, errors.New("webhook event has no id")
+	}
+	if err := applyPaymentEvent(s.db, ev); err != nil {
+		// The provider retries on 5xx.
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (s *PaymentsWebhookService) Webhook(c echo.Context) error {
+	payload, err := io.ReadAll(io.LimitReader(c.Request().Body, 1<<20))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "unreadable body"})
+	}
+	sig := strings.TrimSpace(c.Request().Header.Get("Stripe-Signature"))
+	if status, err := s.receive(payload, sig); err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"received": true})
+}
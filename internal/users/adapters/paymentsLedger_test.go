@@ -0,0 +1,184 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+var registerLedgerDriver sync.Once
+
+// ledgerTestSchema is the slice of the Postgres schema the ledger touches.
+const ledgerTestSchema = `
+	CREATE TABLE users (
+		id INTEGER PRIMARY KEY,
+		email TEXT NOT NULL DEFAULT '',
+		first_name TEXT NOT NULL DEFAULT '',
+		last_name TEXT NOT NULL DEFAULT '',
+		payment_customer_id TEXT NULL
+	);
+	CREATE TABLE payment_methods (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		is_default BOOLEAN NOT NULL DEFAULT FALSE
+	);
+	CREATE TABLE payments (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		subscription_id TEXT NULL,
+		provider_charge_id TEXT NULL UNIQUE,
+		amount_cents INT NOT NULL,
+		refunded_cents INT NOT NULL DEFAULT 0,
+		currency TEXT NOT NULL,
+		status TEXT NOT NULL,
+		failure_reason TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE subscription_history (
+		id INTEGER PRIMARY KEY,
+		subscription_id TEXT NOT NULL,
+		charge_id TEXT NULL
+	);
+`
+
+// ledgerTestDB opens a SQLite database with the ledger's tables and a member
+// whose default card is the fake provider test card token.
+func ledgerTestDB(t *testing.T, payments *FakePayments, token string) *sqlx.DB {
+	t.Helper()
+	registerLedgerDriver.Do(func() {
+		sql.Register("sqlite3_ledger", &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				return conn.RegisterFunc("now", func() string { return time.Now().UTC().Format(time.RFC3339) }, false)
+			},
+		})
+		sqlx.BindDriver("sqlite3_ledger", sqlx.QUESTION)
+	})
+	db, err := sqlx.Open("sqlite3_ledger", filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.MustExec(ledgerTestSchema)
+	db.MustExec(`INSERT INTO users (id, email) VALUES (1, 'member@example.com')`)
+
+	pm, err := payments.AttachPaymentMethod("cus_fake_1", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.MustExec(`INSERT INTO payment_methods (id, user_id, is_default) VALUES (?, 1, TRUE)`, pm.ID)
+	return db
+}
+
+func ledgerRow(t *testing.T, db *sqlx.DB, chargeID string) PaymentRow {
+	t.Helper()
+	var p PaymentRow
+	if err := db.Get(&p, paymentSelect+` WHERE provider_charge_id = ?`, chargeID); err != nil {
+		t.Fatalf("ledger row for %s: %v", chargeID, err)
+	}
+	return p
+}
+
+func TestChargeMemberRecordsAndRefunds(t *testing.T) {
+	payments := NewFakePayments("whsec_test")
+	db := ledgerTestDB(t, payments, "pm_card_visa")
+
+	mc := memberCharge{UserID: 1, AmountCents: 2500, Description: "Subscription to basic", IdempotencyKey: "subscribe-1-0"}
+	p, err := chargeMember(db, payments, mc)
+	if err != nil {
+		t.Fatalf("chargeMember: %v", err)
+	}
+	if p.Status != core.ChargeSucceeded || p.ProviderChargeID == "" {
+		t.Fatalf("charge = %+v, want a succeeded charge", p)
+	}
+	if got := ledgerRow(t, db, p.ProviderChargeID); got.AmountCents != 2500 || got.Status != core.ChargeSucceeded {
+		t.Errorf("ledger row = %+v", got)
+	}
+
+	// A retry with the same key gets the same charge and no second row.
+	again, err := chargeMember(db, payments, mc)
+	if err != nil || again.ProviderChargeID != p.ProviderChargeID {
+		t.Fatalf("retried charge = %+v, %v; want %s", again, err, p.ProviderChargeID)
+	}
+	var rows int
+	db.Get(&rows, `SELECT COUNT(*) FROM payments`)
+	if rows != 1 {
+		t.Errorf("ledger has %d rows, want 1", rows)
+	}
+
+	refundCharge(db, payments, p)
+	if got := ledgerRow(t, db, p.ProviderChargeID); got.Status != "refunded" || got.RefundedCents != 2500 {
+		t.Errorf("after refund ledger row = %+v", got)
+	}
+	if _, err := payments.Refund(p.ProviderChargeID, 1, "extra"); err == nil {
+		t.Error("provider still had something left to refund")
+	}
+}
+
+func TestChargeMemberDeclined(t *testing.T) {
+	payments := NewFakePayments("whsec_test")
+	db := ledgerTestDB(t, payments, "pm_card_chargeDeclined")
+
+	p, err := chargeMember(db, payments, memberCharge{UserID: 1, AmountCents: 2500, IdempotencyKey: "subscribe-1-0"})
+	var decline *core.DeclineError
+	if !errors.As(err, &decline) {
+		t.Fatalf("err = %v, want a decline", err)
+	}
+	// The provider returns no charge for a decline; the attempt is still kept.
+	var got PaymentRow
+	if err := db.Get(&got, paymentSelect+` WHERE user_id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != core.ChargeFailed || got.FailureReason == "" || got.ProviderChargeID != "" {
+		t.Errorf("ledger row = %+v, want a failed attempt with its reason", got)
+	}
+	// Nothing was taken, so there is nothing to give back.
+	refundCharge(db, payments, p)
+	if err := db.Get(&got, paymentSelect+` WHERE user_id = 1`); err != nil || got.Status != core.ChargeFailed {
+		t.Errorf("declined charge after refund = %+v, %v", got, err)
+	}
+}
+
+func TestRefundChargeKeepsOwnedCharge(t *testing.T) {
+	payments := NewFakePayments("whsec_test")
+	db := ledgerTestDB(t, payments, "pm_card_visa")
+
+	p, err := chargeMember(db, payments, memberCharge{UserID: 1, AmountCents: 2500, IdempotencyKey: "change-sub-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another request sharing the key saved its change with this charge.
+	db.MustExec(`INSERT INTO subscription_history (subscription_id, charge_id) VALUES ('sub', ?)`, p.ProviderChargeID)
+
+	refundCharge(db, payments, p)
+	if got := ledgerRow(t, db, p.ProviderChargeID); got.Status != core.ChargeSucceeded || got.RefundedCents != 0 {
+		t.Errorf("owned charge was refunded: %+v", got)
+	}
+	if _, err := payments.Refund(p.ProviderChargeID, 2500, "check"); err != nil {
+		t.Errorf("provider refunded the owned charge: %v", err)
+	}
+}
+
+func TestChargeMemberRefundsUnrecordedCharge(t *testing.T) {
+	payments := NewFakePayments("whsec_test")
+	db := ledgerTestDB(t, payments, "pm_card_visa")
+	db.MustExec(`DROP TABLE payments`)
+
+	p, err := chargeMember(db, payments, memberCharge{UserID: 1, AmountCents: 2500, IdempotencyKey: "subscribe-1-0"})
+	if err == nil {
+		t.Fatalf("chargeMember = %+v, want the ledger error", p)
+	}
+	// The provider charge behind the key was given back in full.
+	ch, _ := payments.Charge(core.ChargeRequest{IdempotencyKey: "subscribe-1-0"})
+	if _, err := payments.Refund(ch.ID, 1, "check"); err == nil {
+		t.Error("unrecorded charge was left with the member")
+	}
+}
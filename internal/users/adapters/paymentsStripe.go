@@ -0,0 +1,227 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+)
+
+const defaultStripeAPIBase = "https://api.stripe.com"
+
+// StripePayments implements ports.TakingPayments against the Stripe API
+// (or anything speaking it, such as stripe-mock via STRIPE_API_BASE).
+// Charges are off-session PaymentIntents confirmed immediately.
+type StripePayments struct {
+	secretKey     string
+	webhookSecret string
+	base          string
+	client        *http.Client
+}
+
+func NewStripePayments(secretKey, webhookSecret string) *StripePayments {
+	base := strings.TrimRight(os.Getenv("STRIPE_API_BASE"), "/")
+	if base == "" {
+		base = defaultStripeAPIBase
+	}
+	return &StripePayments{
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		base:          base,
+		client:        &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// post sends a form-encoded request and decodes the response into out.
+// Card errors come back as *core.DeclineError.
+func (p *StripePayments) post(path string, form url.Values, idempotencyKey string, out any) error {
+	if p.secretKey == "" {
+		return fmt.Errorf("stripe: STRIPE_SECRET_KEY not configured")
+	}
+	req, err := http.NewRequest(http.MethodPost, p.base+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("stripe: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		var se stripeError
+		_ = json.Unmarshal(body, &se)
+		if se.Error.Type == "card_error" {
+			return &core.DeclineError{Code: se.Error.Code, Message: se.Error.Message}
+		}
+		if se.Error.Message != "" {
+			return fmt.Errorf("stripe: %s", se.Error.Message)
+		}
+		return fmt.Errorf("stripe: HTTP %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (p *StripePayments) CreateCustomer(customer core.PaymentCustomer) (string, error) {
+	form := url.Values{}
+	form.Set("email", customer.Email)
+	form.Set("name", customer.Name)
+	form.Set("metadata[user_id]", strconv.Itoa(customer.UserID))
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := p.post("/v1/customers", form, "customer-"+strconv.Itoa(customer.UserID), &out); err != nil {
+		return "", err
+	}
+	return out.ID, nil
+}
+
+type stripePaymentMethod struct {
+	ID   string `json:"id"`
+	Card struct {
+		Brand    string `json:"brand"`
+		Last4    string `json:"last4"`
+		ExpMonth int    `json:"exp_month"`
+		ExpYear  int    `json:"exp_year"`
+	} `json:"card"`
+}
+
+func (p *StripePayments) AttachPaymentMethod(customerID, token string) (core.PaymentMethod, error) {
+	form := url.Values{}
+	form.Set("customer", customerID)
+	var pm stripePaymentMethod
+	if err := p.post("/v1/payment_methods/"+url.PathEscape(token)+"/attach", form, "", &pm); err != nil {
+		return core.PaymentMethod{}, err
+	}
+	return core.PaymentMethod{
+		ID:       pm.ID,
+		Brand:    pm.Card.Brand,
+		Last4:    pm.Card.Last4,
+		ExpMonth: pm.Card.ExpMonth,
+		ExpYear:  pm.Card.ExpYear,
+	}, nil
+}
+
+type stripePaymentIntent struct {
+	ID               string `json:"id"`
+	Amount           int    `json:"amount"`
+	Status           string `json:"status"`
+	LastPaymentError *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+func (p *StripePayments) Charge(req core.ChargeRequest) (core.Charge, error) {
+	if req.PaymentMethodID == "" {
+		return core.Charge{}, &core.DeclineError{Code: "no_payment_method", Message: "No payment method on file"}
+	}
+	form := url.Values{}
+	form.Set("amount", strconv.Itoa(req.AmountCents))
+	form.Set("currency", req.Currency)
+	form.Set("customer", req.CustomerID)
+	form.Set("payment_method", req.PaymentMethodID)
+	form.Set("description", req.Description)
+	form.Set("confirm", "true")
+	form.Set("off_session", "true")
+	form.Set("metadata[user_id]", strconv.Itoa(req.UserID))
+	var pi stripePaymentIntent
+	if err := p.post("/v1/payment_intents", form, req.IdempotencyKey, &pi); err != nil {
+		return core.Charge{}, err
+	}
+	switch pi.Status {
+	case "succeeded":
+		return core.Charge{ID: pi.ID, AmountCents: pi.Amount, Status: core.ChargeSucceeded}, nil
+	case "processing":
+		return core.Charge{ID: pi.ID, AmountCents: pi.Amount, Status: core.ChargePending}, nil
+	case "requires_action":
+		return core.Charge{}, &core.DeclineError{Code: "authentication_required", Message: "The card needs authentication"}
+	}
+	msg := "Payment failed"
+	if pi.LastPaymentError != nil && pi.LastPaymentError.Message != "" {
+		msg = pi.LastPaymentError.Message
+	}
+	return core.Charge{}, &core.DeclineError{Code: pi.Status, Message: msg}
+}
+
+func (p *StripePayments) Refund(chargeID string, amountCents int, idempotencyKey string) (core.Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", chargeID)
+	form.Set("amount", strconv.Itoa(amountCents))
+	var out struct {
+		ID     string `json:"id"`
+		Amount int    `json:"amount"`
+	}
+	if err := p.post("/v1/refunds", form, idempotencyKey, &out); err != nil {
+		return core.Refund{}, err
+	}
+	return core.Refund{ID: out.ID, ChargeID: chargeID, AmountCents: out.Amount}, nil
+}
+
+// ParseWebhook maps the PaymentIntent and refund events to core event types.
+// Other event types come back with their Stripe name and are ignored.
+func (p *StripePayments) ParseWebhook(payload []byte, signature string) (core.PaymentEvent, error) {
+	if err := verifyWebhookSignature(payload, signature, p.webhookSecret, time.Now()); err != nil {
+		return core.PaymentEvent{}, err
+	}
+	var ev struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return core.PaymentEvent{}, err
+	}
+	out := core.PaymentEvent{ID: ev.ID, Type: ev.Type}
+	switch ev.Type {
+	case "payment_intent.succeeded", "payment_intent.payment_failed":
+		var pi stripePaymentIntent
+		if err := json.Unmarshal(ev.Data.Object, &pi); err != nil {
+			return core.PaymentEvent{}, err
+		}
+		out.ChargeID, out.AmountCents = pi.ID, pi.Amount
+		out.Type = core.PaymentEventSucceeded
+		if ev.Type == "payment_intent.payment_failed" {
+			out.Type = core.PaymentEventFailed
+			out.FailureReason = "Payment failed"
+			if pi.LastPaymentError != nil && pi.LastPaymentError.Message != "" {
+				out.FailureReason = pi.LastPaymentError.Message
+			}
+		}
+	case "charge.refunded":
+		var ch struct {
+			PaymentIntent  string `json:"payment_intent"`
+			AmountRefunded int    `json:"amount_refunded"`
+		}
+		if err := json.Unmarshal(ev.Data.Object, &ch); err != nil {
+			return core.PaymentEvent{}, err
+		}
+		out.Type, out.ChargeID, out.AmountCents = core.PaymentEventRefunded, ch.PaymentIntent, ch.AmountRefunded
+	}
+	return out, nil
+}
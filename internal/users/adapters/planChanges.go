@@ -0,0 +1,237 @@
+package adapters
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// subscriptionState is what a plan change needs to know about the current row.
+type subscriptionState struct {
+	ID              string `db:"id"`
+	Status          string `db:"status"`
+	PlanID          string `db:"plan_id"`
+	PriceCents      int    `db:"price_cents"`
+	StartDate       string `db:"start_date"`
+	NextBillingDate string `db:"next_billing_date"`
+	PendingPlanID   string `db:"pending_plan_id"`
+}
+
+const subscriptionStateSelect = `
+	SELECT s.id, s.status, s.plan_id, COALESCE(s.price_cents, p.price_cents) AS price_cents,
+	       s.start_date, s.next_billing_date, COALESCE(s.pending_plan_id, '') AS pending_plan_id
+	FROM subscriptions s
+	JOIN plans p ON p.id = s.plan_id
+	WHERE s.id = ?`
+
+func loadSubscriptionState(db *sqlx.DB, subID string) (subscriptionState, error) {
+	var s subscriptionState
+	err := db.Get(&s, db.Rebind(subscriptionStateSelect), subID)
+	return s, err
+}
+
+// lockSubscriptionState loads the row and holds it until tx ends. NO KEY
+// UPDATE lets the payment recorded outside tx still reference it.
+func lockSubscriptionState(tx *sqlx.Tx, subID string) (subscriptionState, error) {
+	var s subscriptionState
+	err := tx.Get(&s, tx.Rebind(subscriptionStateSelect+` FOR NO KEY UPDATE OF s`), subID)
+	return s, err
+}
+
+// planChangePreview is what picking a plan would do, shown before the
+// member confirms. AmountDueCents is charged right away; a negative one is
+// credited to the next renewal.
+type planChangePreview struct {
+	PlanID         string          `json:"planId"`
+	FromPlanID     string          `json:"fromPlanId,omitempty"`
+	Timing         string          `json:"timing"`
+	EffectiveDate  string          `json:"effectiveDate"`
+	NewPriceCents  int             `json:"newPriceCents"`
+	AmountDueCents int             `json:"amountDueCents"`
+	Proration      *core.Proration `json:"proration,omitempty"`
+}
+
+// previewPlanChange prices moving cur to planID. Without an active
+// subscription it's a new one, paid in full today. timing "" picks
+// immediate for upgrades and period_end otherwise.
+func previewPlanChange(cur subscriptionState, t subscriptionTarget, planID, timing string, now time.Time) planChangePreview {
+	today := now.UTC().Format("2006-01-02")
+	p := planChangePreview{PlanID: planID, NewPriceCents: t.AmountCents}
+	if cur.Status != "active" {
+		p.Timing, p.EffectiveDate, p.AmountDueCents = core.ChangeImmediate, today, t.AmountCents
+		return p
+	}
+	p.FromPlanID = cur.PlanID
+	if cur.PlanID == planID {
+		p.Timing, p.EffectiveDate = core.ChangeImmediate, today
+		return p
+	}
+	if timing == "" {
+		timing = core.ChangePeriodEnd
+		if t.AmountCents > cur.PriceCents {
+			timing = core.ChangeImmediate
+		}
+	}
+	p.Timing = timing
+	if timing == core.ChangePeriodEnd {
+		p.EffectiveDate = cur.NextBillingDate
+		return p
+	}
+	end, err := time.Parse("2006-01-02", cur.NextBillingDate)
+	if err != nil {
+		end = now
+	}
+	pr := core.Prorate(cur.PriceCents, t.AmountCents, billingPeriodStart(cur.StartDate, cur.NextBillingDate, now), end, now)
+	p.EffectiveDate, p.AmountDueCents, p.Proration = today, pr.AmountDueCents, &pr
+	return p
+}
+
+// changePlan moves an active subscription to req.PlanID, now with proration
+// or at the end of the period. Picking the current plan drops a scheduled change.
+func (m *MeAPIService) changePlan(c echo.Context, uid int, cur subscriptionState, t subscriptionTarget, req setSubReq) error {
+	now := time.Now()
+	if cur.PlanID == req.PlanID {
+		// An account-wide subscription still gets bound to the chosen car.
+		if t.CarID != "" {
+			q := m.db.Rebind(`UPDATE subscriptions SET car_id = ? WHERE id = ? AND car_id IS NULL`)
+			if _, err := m.db.Exec(q, t.CarID, cur.ID); err != nil {
+				if isUniqueViolation(err) {
+					return c.JSON(http.StatusConflict, map[string]string{"error": "This car already has a subscription."})
+				}
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			}
+		}
+		if cur.PendingPlanID != "" {
+			q := m.db.Rebind(`UPDATE subscriptions SET pending_plan_id = NULL, pending_price_cents = NULL WHERE id = ?`)
+			if _, err := m.db.Exec(q, cur.ID); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			}
+			_ = recordSubscriptionEvent(m.db, subscriptionEvent{
+				SubscriptionID: cur.ID,
+				UserID:         uid,
+				Event:          "change_canceled",
+				FromStatus:     cur.Status,
+				ToStatus:       cur.Status,
+				Detail:         "kept " + cur.PlanID + " instead of " + cur.PendingPlanID,
+			})
+		}
+		return m.GetMySubscription(c)
+	}
+
+	preview := previewPlanChange(cur, t, req.PlanID, req.Timing, now)
+	if preview.Timing == core.ChangePeriodEnd {
+		q := m.db.Rebind(`UPDATE subscriptions SET pending_plan_id = ?, pending_price_cents = ? WHERE id = ?`)
+		if _, err := m.db.Exec(q, req.PlanID, t.PriceCents, cur.ID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		_ = recordSubscriptionEvent(m.db, subscriptionEvent{
+			SubscriptionID: cur.ID,
+			UserID:         uid,
+			Event:          "change_scheduled",
+			FromStatus:     cur.Status,
+			ToStatus:       cur.Status,
+			AmountCents:    &preview.NewPriceCents,
+			Detail:         cur.PlanID + " -> " + req.PlanID,
+			PeriodStart:    preview.EffectiveDate,
+		})
+		return m.GetMySubscription(c)
+	}
+
+	// Lock the row before pricing and charging: a second request for the
+	// same change waits here, then finds it made and charges nothing.
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer tx.Rollback()
+	locked, err := lockSubscriptionState(tx, cur.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if locked.Status == core.SubscriptionActive && locked.PlanID == req.PlanID {
+		return m.GetMySubscription(c)
+	}
+	if locked.Status != core.SubscriptionActive || locked.PlanID != cur.PlanID {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "This subscription changed while you were choosing; please try again."})
+	}
+	cur = locked
+	preview = previewPlanChange(cur, t, req.PlanID, core.ChangeImmediate, now)
+
+	// The key changes with every plan change that saved and every one whose
+	// charge was given back, so a retry replays only its own charge.
+	var attempt int
+	q := tx.Rebind(`
+		SELECT (SELECT COUNT(*) FROM subscription_history WHERE subscription_id = ? AND event = 'plan_changed')
+		     + (SELECT COUNT(*) FROM payments WHERE subscription_id = ? AND status = 'refunded')
+	`)
+	if err := tx.Get(&attempt, q, cur.ID, cur.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	var payment PaymentRow
+	if preview.AmountDueCents > 0 {
+		var err error
+		payment, err = chargeMember(m.db, m.payments, memberCharge{
+			UserID:         uid,
+			SubscriptionID: cur.ID,
+			AmountCents:    preview.AmountDueCents,
+			Description:    "Change to " + req.PlanID + " (prorated)",
+			IdempotencyKey: "change-" + cur.ID + "-" + strconv.Itoa(attempt) + "-" + req.PlanID + "-" + defaultPaymentMethodID(m.db, uid),
+		})
+		if err != nil {
+			return c.JSON(paymentStatus(err), map[string]string{"error": err.Error()})
+		}
+	}
+	if err := applyPlanChange(tx, uid, cur, t, req.PlanID, preview, payment); err != nil {
+		refundCharge(m.db, m.payments, payment)
+		if isUniqueViolation(err) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "This car already has a subscription."})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := tx.Commit(); err != nil {
+		refundCharge(m.db, m.payments, payment)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return m.GetMySubscription(c)
+}
+
+// applyPlanChange saves an immediate plan change, its new period and its
+// history on tx, which holds the subscription's row.
+func applyPlanChange(tx *sqlx.Tx, uid int, cur subscriptionState, t subscriptionTarget, planID string, preview planChangePreview, payment PaymentRow) error {
+	credit := max(0, -preview.AmountDueCents)
+	q := tx.Rebind(`
+		UPDATE subscriptions
+		SET plan_id = ?, price_cents = ?, car_id = COALESCE(NULLIF(?, ''), car_id),
+		    pending_plan_id = NULL, pending_price_cents = NULL, credit_cents = credit_cents + ?
+		WHERE id = ?
+	`)
+	if _, err := tx.Exec(q, planID, t.PriceCents, t.CarID, credit, cur.ID); err != nil {
+		return err
+	}
+	reason := core.PeriodPlanChange
+	switch {
+	case t.AmountCents > cur.PriceCents:
+		reason = core.PeriodUpgrade
+	case t.AmountCents < cur.PriceCents:
+		reason = core.PeriodDowngrade
+	}
+	if err := startSubscriptionPeriod(tx, cur.ID, preview.EffectiveDate, reason); err != nil {
+		return err
+	}
+	return recordSubscriptionEvent(tx, subscriptionEvent{
+		SubscriptionID: cur.ID,
+		UserID:         uid,
+		Event:          "plan_changed",
+		FromStatus:     cur.Status,
+		ToStatus:       cur.Status,
+		AmountCents:    &preview.AmountDueCents,
+		ChargeID:       payment.ProviderChargeID,
+		Detail:         cur.PlanID + " -> " + planID,
+		PeriodStart:    preview.EffectiveDate,
+		PeriodEnd:      cur.NextBillingDate,
+	})
+}
@@ -3,6 +3,7 @@ package adapters
 import (
 	"net/http"
 
+	"github.com/edlingao/hexago/internal/users/core"
 	"github.com/labstack/echo/v4"
 )
 
@@ -17,10 +18,14 @@ func NewPlansAPIService(httpService *echo.Group) *PlansAPIService {
 }
 
 type PlanRow struct {
-	ID           string `db:"id" json:"id"`
-	Name         string `db:"name" json:"name"`
-	PriceCents   int    `db:"price_cents" json:"priceCents"`
-	FeaturesJSON string `db:"features_json" json:"featuresJson"`
+	ID                 string                `db:"id" json:"id"`
+	Name               string                `db:"name" json:"name"`
+	PriceCents         int                   `db:"price_cents" json:"priceCents"`
+	ExtraCarPriceCents *int                  `db:"extra_car_price_cents" json:"extraCarPriceCents"`
+	FeaturesJSON       string                `db:"features_json" json:"featuresJson"`
+	EntitlementsJSON   string                `db:"entitlements_json" json:"-"`
+	Entitlements       core.PlanEntitlements `db:"-" json:"entitlements"`
+	PackageIDs         []string              `db:"-" json:"packageIds"`
 }
 
 func (s *PlansAPIService) ListPlans(c echo.Context) error {
@@ -31,10 +36,18 @@ func (s *PlansAPIService) ListPlans(c echo.Context) error {
 	defer db.Close()
 
 	var plans []PlanRow
-	q := db.Rebind(`SELECT id, name, price_cents, features_json FROM plans ORDER BY price_cents ASC`)
+	q := db.Rebind(`SELECT id, name, price_cents, extra_car_price_cents, features_json, entitlements_json FROM plans ORDER BY price_cents ASC`)
 	if err := db.Select(&plans, q); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	included, err := loadPlanPackages(db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	for i := range plans {
+		plans[i].Entitlements = parseEntitlements(plans[i].EntitlementsJSON)
+		plans[i].PackageIDs = append([]string{}, included[plans[i].ID]...)
+	}
 
 	return c.JSON(http.StatusOK, map[string]any{"plans": plans})
 }
@@ -0,0 +1,145 @@
+package adapters
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Membership QR payloads look like:
+//
+//	CARWASH-<userId>-<issuedAtUnix>-<nonce>-<ed25519SigHex>
+//
+// The signature covers everything before the last dash, so the user ID,
+// issue time and nonce cannot be altered without the server's private key.
+// Scanners verifying offline only ever get the public key.
+const (
+	qrPrefix      = "CARWASH"
+	qrDefaultTTL  = 5 * time.Minute
+	qrAllowedSkew = 30 * time.Second
+	qrNonceBytes  = 12
+)
+
+type qrSigner struct {
+	secret []byte
+	key    ed25519.PrivateKey
+	ttl    time.Duration
+	// dedicated is false when the secret fell back to JWT_SECRET.
+	dedicated bool
+}
+
+type qrClaims struct {
+	UserID   int
+	IssuedAt time.Time
+	Nonce    string
+}
+
+// newQRSignerFromEnv reads QR_SIGNING_SECRET (falling back to JWT_SECRET)
+// and QR_TTL_SECONDS.
+func newQRSignerFromEnv() *qrSigner {
+	secret := os.Getenv("QR_SIGNING_SECRET")
+	dedicated := secret != ""
+	if !dedicated {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	ttl := qrDefaultTTL
+	if s := strings.TrimSpace(os.Getenv("QR_TTL_SECONDS")); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			ttl = time.Duration(n) * time.Second
+		}
+	}
+	q := &qrSigner{secret: []byte(secret), ttl: ttl, dedicated: dedicated}
+	if secret != "" {
+		seed := sha256.Sum256(append([]byte("qr-signing:"), q.secret...))
+		q.key = ed25519.NewKeyFromSeed(seed[:])
+	}
+	return q
+}
+
+// publicKey is what scanners verify codes with while offline.
+func (q *qrSigner) publicKey() ed25519.PublicKey {
+	return q.key.Public().(ed25519.PublicKey)
+}
+
+func (q *qrSigner) sign(body string) string {
+	return hex.EncodeToString(ed25519.Sign(q.key, []byte(body)))
+}
+
+// Issue returns a fresh signed payload for userID and when it stops being valid.
+func (q *qrSigner) Issue(userID int, now time.Time) (string, time.Time, error) {
+	if len(q.secret) == 0 {
+		return "", time.Time{}, errors.New("QR signing not configured")
+	}
+	nonce := make([]byte, qrNonceBytes)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", time.Time{}, err
+	}
+	body := fmt.Sprintf("%s-%d-%d-%s", qrPrefix, userID, now.Unix(), hex.EncodeToString(nonce))
+	return body + "-" + q.sign(body), now.Add(q.ttl), nil
+}
+
+// Verify checks format, signature and age. On failure it returns a
+// denial reason; claims are still filled in when the signature is valid
+// so that expired codes can be attributed to their member.
+func (q *qrSigner) Verify(qr string, now time.Time) (qrClaims, string) {
+	if qr == "" {
+		return qrClaims{}, "Missing qr"
+	}
+	if len(q.secret) == 0 {
+		return qrClaims{}, "QR signing not configured"
+	}
+
+	parts := strings.Split(qr, "-")
+	if len(parts) != 5 || parts[0] != qrPrefix {
+		return qrClaims{}, "Invalid QR code format"
+	}
+
+	body := strings.Join(parts[:4], "-")
+	sig, err := hex.DecodeString(parts[4])
+	if err != nil || !ed25519.Verify(q.publicKey(), []byte(body), sig) {
+		return qrClaims{}, "Invalid QR signature"
+	}
+
+	uid, err := strconv.Atoi(parts[1])
+	if err != nil || uid <= 0 {
+		return qrClaims{}, "Invalid user id in QR"
+	}
+	iat, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return qrClaims{}, "Invalid QR code format"
+	}
+
+	claims := qrClaims{UserID: uid, IssuedAt: time.Unix(iat, 0).UTC(), Nonce: parts[3]}
+	if claims.IssuedAt.After(now.Add(qrAllowedSkew)) {
+		return claims, "QR code issued in the future"
+	}
+	if now.After(claims.IssuedAt.Add(q.ttl)) {
+		return claims, "QR code expired"
+	}
+	return claims, ""
+}
+
+// consumeQRNonce marks a nonce as redeemed. It reports false when the
+// nonce was already used, which means the QR code is being replayed.
+func consumeQRNonce(db *sqlx.DB, claims qrClaims) (bool, error) {
+	q := db.Rebind(`
+		INSERT INTO qr_token_uses (nonce, user_id, issued_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (nonce) DO NOTHING
+	`)
+	res, err := db.Exec(q, claims.Nonce, claims.UserID, claims.IssuedAt)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/edlingao/hexago/internal/users/core"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 )
@@ -14,11 +14,26 @@ import (
 type ScanAPIService struct {
 	httpService *echo.Group
 	db          *sqlx.DB
+	qr          *qrSigner
 }
 
 type ScanRequest struct {
 	QR         string `json:"qr"`
 	LocationID string `json:"locationId"`
+	// PackageID is the wash being bought; empty means the default wash.
+	PackageID string `json:"packageId"`
+	// IdempotencyKey may also be sent as the Idempotency-Key header.
+	IdempotencyKey string `json:"idempotencyKey"`
+	// Lane is the bay/lane at the site; devices default to their own.
+	Lane string `json:"lane"`
+	// Plate or VIN identify the member by a registered car when there is no
+	// QR (keyboard entry or a plate-recognition camera). UserID picks one of
+	// the candidates returned when the car matches several members. With a
+	// QR, Plate, VIN or CarID name which of the member's cars is being washed.
+	Plate  string `json:"plate"`
+	VIN    string `json:"vin"`
+	UserID int    `json:"userId"`
+	CarID  string `json:"carId"`
 }
 
 type ScanResponse struct {
@@ -29,13 +44,30 @@ type ScanResponse struct {
 	PlanName   string `json:"planName,omitempty"`
 	LocationID string `json:"locationId,omitempty"`
 	UserName   string `json:"userName,omitempty"`
+	// EventID is the recorded wash event; attendants override denials by it.
+	EventID string `json:"eventId,omitempty"`
+	// Vehicle is the matched car for plate/VIN scans.
+	Vehicle *ScanCandidate `json:"vehicle,omitempty"`
+	// Candidates lists the members sharing a plate, or the member's
+	// subscribed cars, when the scan didn't say which one.
+	Candidates []ScanCandidate `json:"candidates,omitempty"`
+	// The package recorded for an allowed wash and what the member pays on
+	// top of their plan. Packages prices the catalog for the upsell.
+	PackageID     string              `json:"packageId,omitempty"`
+	PackageName   string              `json:"packageName,omitempty"`
+	UpchargeCents int                 `json:"upchargeCents,omitempty"`
+	Packages      []core.PackageOffer `json:"packages,omitempty"`
 }
 
 type subscriptionRow struct {
-	ID     string `db:"id"`
-	UserID int    `db:"user_id"`
-	PlanID string `db:"plan_id"`
-	Status string `db:"status"`
+	ID              string `db:"id"`
+	UserID          int    `db:"user_id"`
+	PlanID          string `db:"plan_id"`
+	Status          string `db:"status"`
+	StartDate       string `db:"start_date"`
+	NextBillingDate string `db:"next_billing_date"`
+	// CarID is empty for account-wide subscriptions.
+	CarID string `db:"car_id"`
 }
 
 type planRow struct {
@@ -47,6 +79,7 @@ func NewScanAPIService(httpService *echo.Group) *ScanAPIService {
 	return &ScanAPIService{
 		httpService: httpService,
 		db:          nil,
+		qr:          newQRSignerFromEnv(),
 	}
 }
 
@@ -56,7 +89,15 @@ func (s *ScanAPIService) WithDB(db *sqlx.DB) *ScanAPIService {
 }
 
 func (s *ScanAPIService) RegisterRoutes() {
-	s.httpService.POST("/scan", s.Scan)
+	s.httpService.POST("/scan", s.Scan, RequireScanner(s.db))
+	s.httpService.POST("/scan/batch", s.ScanBatch, RequireScanner(s.db))
+	s.httpService.POST("/scanner/heartbeat", s.Heartbeat, RequireScanner(s.db))
+	s.httpService.GET("/scanner/snapshot", s.Snapshot, RequireScanner(s.db))
+	s.httpService.GET("/scan/packages", s.ListPackages, RequireScanner(s.db))
+	s.httpService.POST("/scan/upgrade", s.Upgrade, RequireScanner(s.db))
+	s.httpService.GET("/scan/override-reasons", s.OverrideReasons, RequirePermission(core.PermScanOverride))
+	s.httpService.POST("/scan/override", s.Override, RequirePermission(core.PermScanOverride))
+	s.httpService.POST("/scan/comp", s.Comp, RequirePermission(core.PermScanOverride))
 }
 
 func (s *ScanAPIService) Scan(c echo.Context) error {
@@ -70,71 +111,219 @@ func (s *ScanAPIService) Scan(c echo.Context) error {
 	}
 	req.QR = strings.TrimSpace(req.QR)
 	req.LocationID = strings.TrimSpace(req.LocationID)
+	req.PackageID = strings.TrimSpace(req.PackageID)
+	req.Plate = normalizePlate(req.Plate)
+	req.VIN = normalizeVIN(req.VIN)
+	req.CarID = strings.TrimSpace(req.CarID)
 
-	userID, parseReason := parseUserIDFromQR(req.QR)
-	if userID == 0 {
-		// log denied event
-		if err := insertWashEvent(s.db, 0, req.LocationID, "denied", req.QR, parseReason); err != nil {
-			// Do not allow success if we failed to record the event
-			return c.JSON(500, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+	// A registered device always scans at the location it is bound to.
+	device, isDevice := deviceFrom(c)
+	if isDevice {
+		req.LocationID = device.LocationID
+	}
+
+	src := scanSourceFrom(c, req.Lane)
+
+	// A retried request gets the original decision back instead of a new event.
+	idem, handled, err := claimScanRequest(c, s.db, req.IdempotencyKey)
+	if handled {
+		return err
+	}
+
+	byVehicle := req.QR == "" && (req.Plate != "" || req.VIN != "")
+	var claims qrClaims
+	var qrReason string
+	var vehicle *ScanCandidate
+	if byVehicle {
+		req.QR = vehicleIdentifier(req.Plate, req.VIN)
+		owners, err := lookupVehicleOwners(s.db, req.Plate, req.VIN)
+		if err != nil {
+			return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to look up vehicle"})
+		}
+		switch {
+		case len(owners) == 0:
+			qrReason = "Vehicle not registered"
+		case len(owners) == 1 && (req.UserID == 0 || req.UserID == owners[0].UserID):
+			vehicle = &owners[0]
+		case req.UserID != 0:
+			for i := range owners {
+				if owners[i].UserID == req.UserID {
+					vehicle = &owners[i]
+				}
+			}
+			if vehicle == nil {
+				qrReason = "Member does not own this vehicle"
+			}
+		default:
+			// Nothing is recorded until the attendant picks a member.
+			return idem.release(c, http.StatusOK, ScanResponse{Allowed: false, Reason: "Several members match this vehicle",
+				LocationID: req.LocationID, Candidates: owners})
 		}
+		if vehicle != nil {
+			claims.UserID = vehicle.UserID
+		}
+	} else {
+		claims, qrReason = s.qr.Verify(req.QR, time.Now().UTC())
+	}
+	userID := claims.UserID
 
-		return c.JSON(http.StatusBadRequest, ScanResponse{Allowed: false, Reason: parseReason, LocationID: req.LocationID})
+	// Staff without locations.all can only scan at their assigned sites.
+	// Checked before the nonce is consumed so the member's code stays usable.
+	if p, _ := principalFrom(c); !isDevice && !p.CanAccessLocation(req.LocationID) {
+		reason := "Not assigned to this location"
+		eventID, err := insertWashEvent(s.db, src, userID, req.LocationID, "denied", req.QR, reason)
+		if err != nil {
+			return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+		}
+		return idem.respond(c, http.StatusForbidden, ScanResponse{Allowed: false, Reason: reason, LocationID: req.LocationID, EventID: eventID})
+	}
+	// Settle which car this is before the code is used up, so the attendant
+	// can pick one and resend the same code.
+	if qrReason == "" && !byVehicle {
+		var candidates []ScanCandidate
+		vehicle, candidates, qrReason, err = qrVehicle(s.db, userID, req)
+		if err != nil {
+			return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to look up vehicle"})
+		}
+		if len(candidates) > 0 {
+			return idem.release(c, http.StatusOK, ScanResponse{Allowed: false, Reason: "Choose the member's vehicle at the lane",
+				UserID: userID, LocationID: req.LocationID, UserName: scanUserDisplayName(s.db, userID), Candidates: candidates})
+		}
 	}
+	if qrReason == "" && !byVehicle {
+		fresh, err := consumeQRNonce(s.db, claims)
+		if err != nil {
+			return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+		}
+		if !fresh {
+			qrReason = "QR code already used"
+		}
+	}
+	if qrReason != "" {
+		// log denied event; userID is only set when the signature checked out
+		eventID, err := insertWashEvent(s.db, src, userID, req.LocationID, "denied", req.QR, qrReason)
+		if err != nil {
+			// Do not allow success if we failed to record the event
+			return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+		}
 
-	// Validate active subscription
-	var sub subscriptionRow
-	err := s.db.Get(&sub, s.db.Rebind(`
-		SELECT id, user_id, plan_id, status
-		FROM subscriptions
-		WHERE user_id = ? AND status = 'active'
-		LIMIT 1
-	`), userID)
+		// An unknown plate is an ordinary miss for a camera feed, not a bad request.
+		status := http.StatusBadRequest
+		if userID != 0 || byVehicle {
+			status = http.StatusOK
+		}
+		return idem.respond(c, status, ScanResponse{Allowed: false, Reason: qrReason, UserID: userID, LocationID: req.LocationID, EventID: eventID})
+	}
 
+	// Validate the car is covered by an active subscription
+	req.CarID = ""
+	if vehicle != nil {
+		req.CarID = vehicle.CarID
+	}
+	sub, err := coveringSubscription(s.db, userID, req.CarID)
 	if err != nil {
-		reason := "No active subscription"
-		_ = insertWashEvent(s.db, userID, req.LocationID, "denied", req.QR, reason)
-		return c.JSON(http.StatusOK, ScanResponse{Allowed: false, Reason: reason, UserID: userID, LocationID: req.LocationID,
-			UserName: scanUserDisplayName(s.db, userID)})
+		reason := uncoveredReason(s.db, userID, req.CarID)
+		ev := newWashEvent(src, userID, req.LocationID, "denied", req.QR, reason)
+		ev.CarID = req.CarID
+		if _, err := writeWashEvent(s.db, ev); err != nil {
+			return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+		}
+		washFeed.publish(ev)
+		return idem.respond(c, http.StatusOK, ScanResponse{Allowed: false, Reason: reason, UserID: userID, LocationID: req.LocationID,
+			UserName: scanUserDisplayName(s.db, userID), EventID: ev.ID, Vehicle: vehicle})
 	}
 
 	// Lookup plan
 	var plan planRow
 	_ = s.db.Get(&plan, s.db.Rebind(`SELECT id, name FROM plans WHERE id = ? LIMIT 1`), sub.PlanID)
 
-	_ = insertWashEvent(s.db, userID, req.LocationID, "allowed", req.QR, "")
-
-	return c.JSON(http.StatusOK, ScanResponse{
-		Allowed:    true,
+	resp := ScanResponse{
 		UserID:     userID,
 		PlanID:     sub.PlanID,
 		PlanName:   plan.Name,
 		LocationID: req.LocationID,
 		UserName:   scanUserDisplayName(s.db, userID),
-	})
+		Vehicle:    vehicle,
+	}
+
+	// Price the requested package, or the plan's default, for this member.
+	offers, err := packageOffers(s.db, sub.PlanID)
+	if err != nil {
+		return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+	}
+	pkg, reason := core.ChoosePackage(offers, req.PackageID)
+	if reason != "" {
+		ev := newWashEvent(src, userID, req.LocationID, "denied", req.QR, reason)
+		ev.CarID = req.CarID
+		if _, err := writeWashEvent(s.db, ev); err != nil {
+			return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+		}
+		washFeed.publish(ev)
+		resp.Reason, resp.EventID = reason, ev.ID
+		return idem.respond(c, http.StatusOK, resp)
+	}
+
+	ev, err := s.admit(sub, req, src, pkg)
+	if err != nil {
+		return idem.respond(c, 500, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+	}
+	resp.Allowed = ev.Result == "allowed"
+	resp.Reason = ev.Reason
+	resp.EventID = ev.ID
+	if resp.Allowed && pkg != nil {
+		resp.PackageID, resp.PackageName, resp.UpchargeCents = pkg.PackageID, pkg.Name, pkg.UpchargeCents
+	}
+	if resp.Allowed {
+		resp.Packages = offers
+	}
+	return idem.respond(c, http.StatusOK, resp)
 }
 
-func parseUserIDFromQR(qr string) (int, string) {
-	if qr == "" {
-		return 0, "Missing qr"
+// admit runs the cooldown and entitlement checks and records the wash under
+// a per-member lock, so two lanes scanning the same member at once can't
+// both get through. An allowed wash is recorded with pkg, if any. It returns
+// the recorded event.
+func (s *ScanAPIService) admit(sub subscriptionRow, req ScanRequest, src scanSource, pkg *core.PackageOffer) (WashEvent, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return WashEvent{}, err
 	}
-	parts := strings.Split(qr, "-")
-	if len(parts) < 2 || parts[0] != "CARWASH" {
-		return 0, "Invalid QR code format"
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(tx.Rebind(`SELECT pg_advisory_xact_lock(?, ?)`), scanLockNamespace, sub.UserID); err != nil {
+		return WashEvent{}, err
 	}
-	// user_id in your system is numeric (sqlite users.id INTEGER)
-	uidStr := parts[1]
-	uid := 0
-	for _, ch := range uidStr {
-		if ch < '0' || ch > '9' {
-			return 0, "Invalid user id in QR"
-		}
-		uid = uid*10 + int(ch-'0')
+
+	now := time.Now().UTC()
+	usage, err := loadWashUsage(tx, sub.UserID, sub.CarID, billingPeriodStart(sub.StartDate, sub.NextBillingDate, now), now)
+	if err != nil {
+		return WashEvent{}, err
+	}
+
+	reason := ""
+	if cooldown := scanCooldown(); cooldown > 0 && usage.LastWashAt != nil && now.Sub(*usage.LastWashAt) < cooldown {
+		reason = fmt.Sprintf("Already scanned %ds ago", int(now.Sub(*usage.LastWashAt).Seconds()))
+	} else {
+		reason = loadPlanEntitlements(s.db, sub.PlanID).Check(usage, req.LocationID, now)
+	}
+
+	result := "allowed"
+	if reason != "" {
+		result = "denied"
+	}
+	ev := newWashEvent(src, sub.UserID, req.LocationID, result, req.QR, reason)
+	ev.CarID = req.CarID
+	if result == "allowed" && pkg != nil {
+		ev.PackageID, ev.UpchargeCents = pkg.PackageID, pkg.UpchargeCents
 	}
-	if uid <= 0 {
-		return 0, "Invalid user id in QR"
+	if _, err := writeWashEvent(tx, ev); err != nil {
+		return WashEvent{}, err
 	}
-	return uid, ""
+	if err := tx.Commit(); err != nil {
+		return WashEvent{}, err
+	}
+	washFeed.publish(ev)
+	return ev, nil
 }
 
 type scanUserRow struct {
@@ -161,14 +350,39 @@ func scanUserDisplayName(db *sqlx.DB, userID int) string {
 	return fmt.Sprintf("Member #%d", userID)
 }
 
-func insertWashEvent(db *sqlx.DB, userID int, locationID, result, rawQR, reason string) error {
-	id := uuid.NewString()
-	scannedAt := time.Now().UTC().Format(time.RFC3339)
+// insertWashEvent records a scan outside a transaction, pushes it to the live
+// feed and returns its ID.
+func insertWashEvent(db *sqlx.DB, src scanSource, userID int, locationID, result, rawQR, reason string) (string, error) {
+	ev := newWashEvent(src, userID, locationID, result, rawQR, reason)
+	if _, err := writeWashEvent(db, ev); err != nil {
+		return "", err
+	}
+	washFeed.publish(ev)
+	return ev.ID, nil
+}
 
+// writeWashEvent inserts ev. It reports false when an offline event with the
+// same client ID was already recorded. Callers publish once it's committed.
+func writeWashEvent(db sqlx.Ext, ev WashEvent) (bool, error) {
 	q := db.Rebind(`
-		INSERT INTO wash_events (id, user_id, location_id, scanned_at, result, raw_qr, reason)
-		VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, NULLIF(?, ''))
+		INSERT INTO wash_events (id, user_id, location_id, scanned_at, result, raw_qr, reason, offline, client_event_id,
+		                         scanned_by, device_id, lane, override_of, reason_code, note, car_id, package_id, upcharge_cents)
+		VALUES (?, NULLIF(?, 0), NULLIF(?, ''), ?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''),
+		        NULLIF(?, 0), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''),
+		        NULLIF(?, ''), ?)
+		ON CONFLICT (client_event_id) WHERE client_event_id IS NOT NULL DO NOTHING
 	`)
-	_, err := db.Exec(q, id, userID, locationID, scannedAt, result, rawQR, reason)
-	return err
+	// The upcharge is only stored alongside a package.
+	var upcharge any
+	if ev.PackageID != "" {
+		upcharge = ev.UpchargeCents
+	}
+	res, err := db.Exec(q, ev.ID, ev.UserID, ev.LocationID, ev.ScannedAt.Format(time.RFC3339), ev.Result, ev.rawQR, ev.Reason, ev.Offline, ev.clientEventID,
+		ev.ScannedBy, ev.DeviceID, ev.Lane, ev.OverrideOf, ev.ReasonCode, ev.Note, ev.CarID,
+		ev.PackageID, upcharge)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
 }
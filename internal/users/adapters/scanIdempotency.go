@@ -0,0 +1,112 @@
+package adapters
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultScanCooldown = 60 * time.Second
+	// scanLockNamespace is the first key of the per-member advisory lock taken while admitting a wash.
+	scanLockNamespace = 7301
+	// Stored scan decisions older than this are purged by the janitor.
+	scanRequestRetention = 24 * time.Hour
+)
+
+// scanCooldown is how long after an allowed wash the same member is refused
+// (SCAN_COOLDOWN_SECONDS, 0 disables).
+func scanCooldown() time.Duration {
+	if s := strings.TrimSpace(os.Getenv("SCAN_COOLDOWN_SECONDS")); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return defaultScanCooldown
+}
+
+// scanRequest is a claimed idempotency key. A nil *scanRequest (no key sent)
+// just writes the response.
+type scanRequest struct {
+	db    *sqlx.DB
+	scope string
+	key   string
+}
+
+// scanRequestScope keeps keys from different scanners apart.
+func scanRequestScope(c echo.Context) string {
+	if d, ok := deviceFrom(c); ok {
+		return "device:" + d.ID
+	}
+	p, _ := principalFrom(c)
+	return "user:" + strconv.FormatInt(p.UserID, 10)
+}
+
+// claimScanRequest reserves the idempotency key for this request. If the key
+// was already used, handled is true and the stored decision (or a 409 while
+// the first request is still running) has been written.
+func claimScanRequest(c echo.Context, db *sqlx.DB, bodyKey string) (req *scanRequest, handled bool, err error) {
+	key := strings.TrimSpace(c.Request().Header.Get("Idempotency-Key"))
+	if key == "" {
+		key = strings.TrimSpace(bodyKey)
+	}
+	if key == "" {
+		return nil, false, nil
+	}
+	if len(key) > 128 {
+		return nil, true, c.JSON(http.StatusBadRequest, ScanResponse{Allowed: false, Reason: "Idempotency key too long"})
+	}
+
+	req = &scanRequest{db: db, scope: scanRequestScope(c), key: key}
+	res, err := db.Exec(db.Rebind(`
+		INSERT INTO scan_requests (scope, key) VALUES (?, ?)
+		ON CONFLICT (scope, key) DO NOTHING
+	`), req.scope, req.key)
+	if err != nil {
+		return nil, true, c.JSON(http.StatusInternalServerError, map[string]any{"allowed": false, "reason": "Failed to record wash event"})
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return req, false, nil
+	}
+
+	var stored struct {
+		Status   int    `db:"status"`
+		Response string `db:"response_json"`
+	}
+	q := db.Rebind(`SELECT status, COALESCE(response_json, '') AS response_json FROM scan_requests WHERE scope = ? AND key = ?`)
+	if err := db.Get(&stored, q, req.scope, req.key); err != nil || stored.Status == 0 {
+		return nil, true, c.JSON(http.StatusConflict, ScanResponse{Allowed: false, Reason: "Scan already in progress"})
+	}
+	c.Response().Header().Set("Idempotent-Replayed", "true")
+	return nil, true, c.JSONBlob(stored.Status, []byte(stored.Response))
+}
+
+// respond stores the decision under the key and writes it. Server errors
+// release the key so the client's retry runs again.
+func (r *scanRequest) respond(c echo.Context, status int, body any) error {
+	if status >= 500 {
+		return r.release(c, status, body)
+	}
+	if r != nil {
+		if b, err := json.Marshal(body); err == nil {
+			q := r.db.Rebind(`UPDATE scan_requests SET status = ?, response_json = ? WHERE scope = ? AND key = ?`)
+			_, _ = r.db.Exec(q, status, string(b), r.scope, r.key)
+		}
+	}
+	return c.JSON(status, body)
+}
+
+// release writes a response without keeping it, so the key can be reused
+// for the follow-up request.
+func (r *scanRequest) release(c echo.Context, status int, body any) error {
+	if r != nil {
+		_, _ = r.db.Exec(r.db.Rebind(`DELETE FROM scan_requests WHERE scope = ? AND key = ?`), r.scope, r.key)
+	}
+	return c.JSON(status, body)
+}
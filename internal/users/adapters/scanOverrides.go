@@ -0,0 +1,313 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// overrideWindow is how long after a denial an attendant can still let the car through.
+	overrideWindow  = 30 * time.Minute
+	overrideNoteMax = 500
+)
+
+type OverrideRequest struct {
+	DeniedEventID string `json:"deniedEventId"`
+	ReasonCode    string `json:"reasonCode"`
+	Note          string `json:"note"`
+	Lane          string `json:"lane"`
+}
+
+type CompRequest struct {
+	LocationID string `json:"locationId"`
+	ReasonCode string `json:"reasonCode"`
+	Note       string `json:"note"`
+	Lane       string `json:"lane"`
+	// PackageID is the package given away, if any; comps carry no upcharge.
+	PackageID string `json:"packageId"`
+}
+
+type OverrideResponse struct {
+	EventID    string `json:"eventId"`
+	Result     string `json:"result"`
+	OverrideOf string `json:"overrideOf,omitempty"`
+	UserID     int    `json:"userId,omitempty"`
+	UserName   string `json:"userName,omitempty"`
+	LocationID string `json:"locationId"`
+	ReasonCode string `json:"reasonCode"`
+}
+
+// checkReason validates a reason code against its catalog. "other" needs a note.
+func checkReason(catalog []core.ReasonCode, code, note string) string {
+	if !core.IsReasonCode(catalog, code) {
+		return "unknown reason code"
+	}
+	if code == "other" && note == "" {
+		return "a note is required for reason 'other'"
+	}
+	if len(note) > overrideNoteMax {
+		return "note is too long"
+	}
+	return ""
+}
+
+func (s *ScanAPIService) OverrideReasons(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{
+		"override": core.OverrideReasons,
+		"comp":     core.CompReasons,
+	})
+}
+
+// Override lets a recently denied scan through. The new allowed_override
+// event points at the denial and counts against the member's plan.
+func (s *ScanAPIService) Override(c echo.Context) error {
+	p, _ := principalFrom(c)
+	var req OverrideRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+	}
+	req.DeniedEventID = strings.TrimSpace(req.DeniedEventID)
+	req.ReasonCode = strings.TrimSpace(req.ReasonCode)
+	req.Note = strings.TrimSpace(req.Note)
+	if req.DeniedEventID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "deniedEventId is required"})
+	}
+	if msg := checkReason(core.OverrideReasons, req.ReasonCode, req.Note); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer tx.Rollback()
+
+	// Locking the denial keeps two attendants from overriding it at once.
+	var denied struct {
+		UserID     sql.NullInt64  `db:"user_id"`
+		LocationID sql.NullString `db:"location_id"`
+		ScannedAt  time.Time      `db:"scanned_at"`
+		Result     string         `db:"result"`
+		RawQR      string         `db:"raw_qr"`
+		Reason     sql.NullString `db:"reason"`
+		CarID      sql.NullString `db:"car_id"`
+	}
+	q := tx.Rebind(`SELECT user_id, location_id, scanned_at, result, raw_qr, reason, car_id FROM wash_events WHERE id = ? FOR UPDATE`)
+	if err := tx.Get(&denied, q, req.DeniedEventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "wash event not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !p.CanAccessLocation(denied.LocationID.String) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
+	}
+	if denied.Result != "denied" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "only denied scans can be overridden"})
+	}
+	if time.Since(denied.ScannedAt) > overrideWindow {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "denial is too old to override"})
+	}
+	var existing string
+	err = tx.Get(&existing, tx.Rebind(`SELECT id FROM wash_events WHERE override_of = ? LIMIT 1`), req.DeniedEventID)
+	if err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "scan already overridden", "eventId": existing})
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	ev := newWashEvent(scanSourceFrom(c, req.Lane), int(denied.UserID.Int64), denied.LocationID.String, "allowed_override", denied.RawQR, "")
+	ev.OverrideOf = req.DeniedEventID
+	ev.CarID = denied.CarID.String
+	ev.ReasonCode = req.ReasonCode
+	ev.Note = req.Note
+	if _, err := writeWashEvent(tx, ev); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	washFeed.publish(ev)
+	writeAudit(s.db, p.UserID, "scan.override", "wash_event", ev.ID, map[string]any{
+		"overrideOf":   req.DeniedEventID,
+		"deniedReason": denied.Reason.String,
+		"userId":       ev.UserID,
+		"locationId":   ev.LocationID,
+		"reasonCode":   req.ReasonCode,
+		"note":         req.Note,
+	})
+
+	resp := OverrideResponse{
+		EventID:    ev.ID,
+		Result:     ev.Result,
+		OverrideOf: ev.OverrideOf,
+		UserID:     ev.UserID,
+		LocationID: ev.LocationID,
+		ReasonCode: ev.ReasonCode,
+	}
+	if ev.UserID > 0 {
+		resp.UserName = scanUserDisplayName(s.db, ev.UserID)
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// Comp records a free wash for someone without a membership.
+func (s *ScanAPIService) Comp(c echo.Context) error {
+	p, _ := principalFrom(c)
+	var req CompRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+	}
+	req.LocationID = strings.TrimSpace(req.LocationID)
+	req.ReasonCode = strings.TrimSpace(req.ReasonCode)
+	req.Note = strings.TrimSpace(req.Note)
+	req.PackageID = strings.TrimSpace(req.PackageID)
+	if req.LocationID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "locationId is required"})
+	}
+	if msg := checkReason(core.CompReasons, req.ReasonCode, req.Note); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+	if !p.CanAccessLocation(req.LocationID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
+	}
+	var exists int
+	if err := s.db.Get(&exists, s.db.Rebind(`SELECT COUNT(1) FROM locations WHERE id = ?`), req.LocationID); err != nil || exists == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown location"})
+	}
+
+	ev := newWashEvent(scanSourceFrom(c, req.Lane), 0, req.LocationID, "comp", "", "")
+	ev.ReasonCode = req.ReasonCode
+	ev.Note = req.Note
+	if req.PackageID != "" {
+		offers, err := packageOffers(s.db, "")
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		pkg, reason := core.ChoosePackage(offers, req.PackageID)
+		if reason != "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": reason})
+		}
+		ev.PackageID = pkg.PackageID
+	}
+	if _, err := writeWashEvent(s.db, ev); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	washFeed.publish(ev)
+	writeAudit(s.db, p.UserID, "scan.comp", "wash_event", ev.ID, map[string]any{
+		"locationId": req.LocationID,
+		"reasonCode": req.ReasonCode,
+		"note":       req.Note,
+		"packageId":  req.PackageID,
+	})
+
+	return c.JSON(http.StatusCreated, OverrideResponse{
+		EventID:    ev.ID,
+		Result:     ev.Result,
+		LocationID: ev.LocationID,
+		ReasonCode: ev.ReasonCode,
+	})
+}
+
+type AdminOverride struct {
+	ID            string    `json:"id" db:"id"`
+	Result        string    `json:"result" db:"result"`
+	ScannedAt     time.Time `json:"scannedAt" db:"scanned_at"`
+	LocationID    string    `json:"locationId" db:"location_id"`
+	LocationName  string    `json:"locationName" db:"location_name"`
+	UserID        int       `json:"userId,omitempty" db:"user_id"`
+	UserName      string    `json:"userName,omitempty" db:"user_name"`
+	ScannedBy     int64     `json:"scannedBy,omitempty" db:"scanned_by"`
+	ScannedByName string    `json:"scannedByName,omitempty" db:"scanned_by_name"`
+	Lane          string    `json:"lane,omitempty" db:"lane"`
+	ReasonCode    string    `json:"reasonCode" db:"reason_code"`
+	Note          string    `json:"note,omitempty" db:"note"`
+	OverrideOf    string    `json:"overrideOf,omitempty" db:"override_of"`
+	DeniedReason  string    `json:"deniedReason,omitempty" db:"denied_reason"`
+}
+
+type overrideCount struct {
+	Label string `json:"label" db:"label"`
+	Count int    `json:"count" db:"cnt"`
+}
+
+// ListOverrides reports attendant overrides and comp washes over the last
+// ?days (default 30), with totals by reason and by attendant.
+func (a *AdminAPIService) ListOverrides(c echo.Context) error {
+	days := 30
+	if ds := strings.TrimSpace(c.QueryParam("days")); ds != "" {
+		if v, err := strconv.Atoi(ds); err == nil && v > 0 && v <= 365 {
+			days = v
+		}
+	}
+	_, locs, ok := scopedLocations(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
+	}
+	locIn, locArgs := locationInClause("e.location_id", locs)
+	args := append([]any{days}, locArgs...)
+	where := `
+		WHERE e.result IN ('allowed_override', 'comp')
+		  AND e.scanned_at >= NOW() - (?::int * interval '1 day')` + locIn
+
+	items := []AdminOverride{}
+	q := a.db.Rebind(`
+		SELECT e.id, e.result, e.scanned_at,
+		       COALESCE(e.location_id, '') AS location_id, COALESCE(l.name, '') AS location_name,
+		       COALESCE(e.user_id, 0) AS user_id,
+		       COALESCE(NULLIF(TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), ''), u.username, '') AS user_name,
+		       COALESCE(e.scanned_by, 0) AS scanned_by,
+		       COALESCE(NULLIF(TRIM(COALESCE(sb.first_name, '') || ' ' || COALESCE(sb.last_name, '')), ''), sb.username, '') AS scanned_by_name,
+		       COALESCE(e.lane, '') AS lane, COALESCE(e.reason_code, '') AS reason_code, COALESCE(e.note, '') AS note,
+		       COALESCE(e.override_of, '') AS override_of, COALESCE(d.reason, '') AS denied_reason
+		FROM wash_events e
+		LEFT JOIN locations l ON l.id = e.location_id
+		LEFT JOIN users u ON u.id = e.user_id
+		LEFT JOIN users sb ON sb.id = e.scanned_by
+		LEFT JOIN wash_events d ON d.id = e.override_of` + where + `
+		ORDER BY e.scanned_at DESC
+		LIMIT 500
+	`)
+	if err := a.db.Select(&items, q, args...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	byReason := []overrideCount{}
+	q = a.db.Rebind(`
+		SELECT e.result || ':' || COALESCE(e.reason_code, '') AS label, COUNT(*) AS cnt
+		FROM wash_events e` + where + `
+		GROUP BY 1
+		ORDER BY cnt DESC, label ASC
+	`)
+	if err := a.db.Select(&byReason, q, args...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	byAttendant := []overrideCount{}
+	q = a.db.Rebind(`
+		SELECT COALESCE(NULLIF(TRIM(COALESCE(sb.first_name, '') || ' ' || COALESCE(sb.last_name, '')), ''), sb.username, 'Unknown') AS label,
+		       COUNT(*) AS cnt
+		FROM wash_events e
+		LEFT JOIN users sb ON sb.id = e.scanned_by` + where + `
+		GROUP BY 1
+		ORDER BY cnt DESC, label ASC
+	`)
+	if err := a.db.Select(&byAttendant, q, args...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"days":        days,
+		"items":       items,
+		"byReason":    byReason,
+		"byAttendant": byAttendant,
+	})
+}
@@ -0,0 +1,214 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx"
+)
+
+// ScanCandidate is a member whose registered car matches a scanned plate or VIN.
+type ScanCandidate struct {
+	UserID             int    `json:"userId" db:"user_id"`
+	UserName           string `json:"userName" db:"user_name"`
+	CarID              string `json:"carId" db:"car_id"`
+	Nickname           string `json:"nickname,omitempty" db:"nickname"`
+	Make               string `json:"make,omitempty" db:"make"`
+	Model              string `json:"model,omitempty" db:"model"`
+	Color              string `json:"color,omitempty" db:"color"`
+	Plate              string `json:"plate,omitempty" db:"plate"`
+	ActiveSubscription bool   `json:"activeSubscription" db:"active_subscription"`
+}
+
+// normalizePlate drops spaces, dashes and other separators so "abc-1234",
+// "ABC 1234" and an LPR read of "ABC1234" all match.
+func normalizePlate(p string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(p) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// vehicleIdentifier is what gets stored as raw_qr for a plate or VIN scan.
+func vehicleIdentifier(plate, vin string) string {
+	if vin != "" {
+		return "vin:" + vin
+	}
+	return "plate:" + plate
+}
+
+// vehicleCandidateSelect reads ScanCandidate rows from cars c. A car is
+// covered by its own subscription or by an account-wide one.
+const vehicleCandidateSelect = `
+	SELECT c.user_id, c.id AS car_id, c.nickname, c.make, c.model, c.color, c.plate,
+	       COALESCE(NULLIF(TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), ''), u.username, '') AS user_name,
+	       EXISTS (SELECT 1 FROM subscriptions s
+	               WHERE s.user_id = c.user_id AND s.status = 'active'
+	                 AND (s.car_id = c.id OR s.car_id IS NULL)) AS active_subscription
+	FROM cars c
+	JOIN users u ON u.id = c.user_id`
+
+// lookupVehicleOwners returns the members with a car matching the VIN, or
+// the plate when no VIN is given. The comparison uses the same normalization
+// as the expression indexes on cars.
+func lookupVehicleOwners(db *sqlx.DB, plate, vin string) ([]ScanCandidate, error) {
+	match, arg := `UPPER(REGEXP_REPLACE(c.plate, '[^A-Za-z0-9]', '', 'g')) = ?`, plate
+	if vin != "" {
+		match, arg = `UPPER(REPLACE(c.vin, ' ', '')) = ?`, vin
+	}
+	q := db.Rebind(vehicleCandidateSelect + `
+		WHERE ` + match + `
+		ORDER BY c.user_id, c.updated_at DESC
+		LIMIT 20
+	`)
+	out := []ScanCandidate{}
+	if err := db.Select(&out, q, arg); err != nil {
+		return nil, err
+	}
+	// One entry per member; a member rarely registers the same plate twice.
+	seen := map[int]bool{}
+	owners := out[:0]
+	for _, o := range out {
+		if !seen[o.UserID] {
+			seen[o.UserID] = true
+			owners = append(owners, o)
+		}
+	}
+	return owners, nil
+}
+
+// memberVehicle returns the member's car, or nil if they don't own it.
+func memberVehicle(db *sqlx.DB, userID int, carID string) (*ScanCandidate, error) {
+	var out []ScanCandidate
+	q := db.Rebind(vehicleCandidateSelect + ` WHERE c.user_id = ? AND c.id = ?`)
+	if err := db.Select(&out, q, userID, carID); err != nil || len(out) == 0 {
+		return nil, err
+	}
+	return &out[0], nil
+}
+
+// memberVehicles lists all of the member's cars.
+func memberVehicles(db *sqlx.DB, userID int) ([]ScanCandidate, error) {
+	out := []ScanCandidate{}
+	q := db.Rebind(vehicleCandidateSelect + `
+		WHERE c.user_id = ?
+		ORDER BY c.created_at
+	`)
+	if err := db.Select(&out, q, userID); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// subscribedVehicles lists the member's cars that have their own active subscription.
+func subscribedVehicles(db *sqlx.DB, userID int) ([]ScanCandidate, error) {
+	out := []ScanCandidate{}
+	q := db.Rebind(vehicleCandidateSelect + `
+		JOIN subscriptions sc ON sc.car_id = c.id AND sc.status = 'active'
+		WHERE c.user_id = ?
+		ORDER BY c.created_at
+	`)
+	if err := db.Select(&out, q, userID); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// coveringSubscription finds the member's active subscription for the car,
+// preferring one bound to it over an account-wide one. An empty carID only
+// matches account-wide subscriptions.
+func coveringSubscription(db *sqlx.DB, userID int, carID string) (subscriptionRow, error) {
+	var sub subscriptionRow
+	err := db.Get(&sub, db.Rebind(`
+		SELECT id, user_id, plan_id, status, start_date, next_billing_date, COALESCE(car_id, '') AS car_id
+		FROM subscriptions
+		WHERE user_id = ? AND status = 'active' AND (car_id = ? OR car_id IS NULL)
+		ORDER BY car_id IS NULL
+		LIMIT 1
+	`), userID, carID)
+	return sub, err
+}
+
+// uncoveredReason explains why the car has no covering subscription: the
+// one it had is paused, past due or canceled, or there never was one.
+func uncoveredReason(db *sqlx.DB, userID int, carID string) string {
+	var sub struct {
+		Status      string `db:"status"`
+		PausedUntil string `db:"paused_until"`
+	}
+	err := db.Get(&sub, db.Rebind(`
+		SELECT status, COALESCE(paused_until, '') AS paused_until
+		FROM subscriptions
+		WHERE user_id = ? AND (car_id = ? OR car_id IS NULL)
+		ORDER BY car_id IS NULL, status = 'canceled', start_date DESC
+		LIMIT 1
+	`), userID, carID)
+	if err == nil {
+		switch sub.Status {
+		case core.SubscriptionPaused:
+			return "Subscription paused until " + sub.PausedUntil
+		case core.SubscriptionPastDue:
+			return "Payment past due"
+		case core.SubscriptionCanceled:
+			return "Subscription canceled"
+		}
+	}
+	var active int
+	_ = db.Get(&active, db.Rebind(`SELECT COUNT(1) FROM subscriptions WHERE user_id = ? AND status = 'active'`), userID)
+	if active > 0 {
+		return "Vehicle not covered by a subscription"
+	}
+	return "No active subscription"
+}
+
+// qrVehicle works out which of the member's cars a QR scan is for: the one
+// named by carId, plate or VIN, else their only car. When they own cars
+// that aren't covered and the scan named none, all of their cars come back
+// as candidates.
+func qrVehicle(db *sqlx.DB, userID int, req ScanRequest) (vehicle *ScanCandidate, candidates []ScanCandidate, reason string, err error) {
+	const notTheirs = "Vehicle is not registered to this member"
+	switch {
+	case req.CarID != "":
+		if vehicle, err = memberVehicle(db, userID, req.CarID); err != nil || vehicle != nil {
+			return vehicle, nil, "", err
+		}
+		return nil, nil, notTheirs, nil
+	case req.Plate != "" || req.VIN != "":
+		owners, err := lookupVehicleOwners(db, req.Plate, req.VIN)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		for i := range owners {
+			if owners[i].UserID == userID {
+				return &owners[i], nil, "", nil
+			}
+		}
+		return nil, nil, notTheirs, nil
+	}
+	// One subscription must not wash every car the member owns: unless the
+	// car at the lane can't matter, staff have to say which one it is.
+	owned, err := memberVehicles(db, userID)
+	if err != nil || len(owned) == 0 {
+		return nil, nil, "", err
+	}
+	if len(owned) == 1 {
+		return &owned[0], nil, "", nil
+	}
+	for _, car := range owned {
+		if !car.ActiveSubscription {
+			return nil, owned, "", nil
+		}
+	}
+	// Every car is covered, so only the subscription it's billed to is open.
+	cars, err := subscribedVehicles(db, userID)
+	if err != nil || len(cars) == 0 {
+		return nil, nil, "", err
+	}
+	if len(cars) == 1 {
+		return &cars[0], nil, "", nil
+	}
+	return nil, cars, "", nil
+}
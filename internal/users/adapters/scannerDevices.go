@@ -0,0 +1,124 @@
+package adapters
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	deviceContextKey = "scannerDevice"
+	deviceKeyHeader  = "X-Device-Key"
+	// deviceKeyPrefix makes leaked keys easy to spot in logs and secret scanners.
+	deviceKeyPrefix = "sdk_"
+)
+
+// ScannerDevice is a registered kiosk. Scans it makes are pinned to its location.
+type ScannerDevice struct {
+	ID         string `db:"id"`
+	Name       string `db:"name"`
+	LocationID string `db:"location_id"`
+	Lane       string `db:"lane"`
+}
+
+func deviceFrom(c echo.Context) (ScannerDevice, bool) {
+	d, ok := c.Get(deviceContextKey).(ScannerDevice)
+	return d, ok && d.ID != ""
+}
+
+// newDeviceKey returns a fresh API key and the prefix shown in the admin list.
+func newDeviceKey() (key, prefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = deviceKeyPrefix + hex.EncodeToString(buf)
+	return key, key[:len(deviceKeyPrefix)+8], nil
+}
+
+// normalizeFingerprint accepts "AB:CD:..." or plain hex and returns lowercase hex.
+func normalizeFingerprint(fp string) string {
+	fp = strings.ToLower(strings.TrimSpace(fp))
+	fp = strings.TrimPrefix(fp, "sha256:")
+	return strings.NewReplacer(":", "", " ", "").Replace(fp)
+}
+
+// deviceCredentials pulls the API key and client certificate fingerprint off
+// the request. The certificate comes from the TLS handshake, or from
+// SCANNER_CERT_HEADER when a trusted proxy terminates TLS.
+func deviceCredentials(c echo.Context) (key, fingerprint string) {
+	req := c.Request()
+	key = strings.TrimSpace(req.Header.Get(deviceKeyHeader))
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		sum := sha256.Sum256(req.TLS.PeerCertificates[0].Raw)
+		fingerprint = hex.EncodeToString(sum[:])
+	} else if h := strings.TrimSpace(os.Getenv("SCANNER_CERT_HEADER")); h != "" {
+		fingerprint = normalizeFingerprint(req.Header.Get(h))
+	}
+	return key, fingerprint
+}
+
+// lookupDevice finds the active device for the credentials and records the heartbeat.
+func lookupDevice(db *sqlx.DB, key, fingerprint, ip string) (ScannerDevice, bool) {
+	var d ScannerDevice
+	var err error
+	if key != "" {
+		q := db.Rebind(`SELECT id, name, location_id, lane FROM scanner_devices WHERE api_key_hash = ? AND revoked_at IS NULL LIMIT 1`)
+		err = db.Get(&d, q, hashAccountToken(key))
+	} else {
+		q := db.Rebind(`SELECT id, name, location_id, lane FROM scanner_devices WHERE cert_fingerprint = ? AND revoked_at IS NULL LIMIT 1`)
+		err = db.Get(&d, q, fingerprint)
+	}
+	if err != nil {
+		return ScannerDevice{}, false
+	}
+	_, _ = db.Exec(db.Rebind(`UPDATE scanner_devices SET last_seen_at = NOW(), last_seen_ip = ? WHERE id = ?`), ip, d.ID)
+	return d, true
+}
+
+// RequireScanner lets a request through if it comes from a registered device
+// or from a signed-in user with scan.perform. Bad device credentials are
+// rejected outright rather than falling back to the session.
+func RequireScanner(db *sqlx.DB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		asStaff := RequirePermission(core.PermScanPerform)(next)
+		return func(c echo.Context) error {
+			key, fp := deviceCredentials(c)
+			if key == "" && fp == "" {
+				return asStaff(c)
+			}
+			d, ok := lookupDevice(db, key, fp, c.RealIP())
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid device credentials"})
+			}
+			c.Set(deviceContextKey, d)
+			return next(c)
+		}
+	}
+}
+
+// Heartbeat lets a kiosk check in and confirm which location it is bound to.
+func (s *ScanAPIService) Heartbeat(c echo.Context) error {
+	d, ok := deviceFrom(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "device credentials required"})
+	}
+	var locName string
+	_ = s.db.Get(&locName, s.db.Rebind(`SELECT name FROM locations WHERE id = ? LIMIT 1`), d.LocationID)
+	return c.JSON(http.StatusOK, map[string]any{
+		"deviceId":     d.ID,
+		"name":         d.Name,
+		"locationId":   d.LocationID,
+		"locationName": locName,
+		"lane":         d.Lane,
+		"serverTime":   time.Now().UTC().Format(time.RFC3339),
+	})
+}
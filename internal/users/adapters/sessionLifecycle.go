@@ -0,0 +1,130 @@
+package adapters
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultSessionTTL = 7 * 24 * time.Hour
+	// Only bump last_seen_at/expires_at this often so every request isn't a write.
+	sessionTouchInterval = 5 * time.Minute
+)
+
+type activeSession struct {
+	ID             int64     `db:"id"`
+	UserID         int64     `db:"user_id"`
+	Role           string    `db:"role"`
+	LastSeenAt     time.Time `db:"last_seen_at"`
+	ImpersonatorID int64     `db:"impersonator_id"`
+	Renewed        bool      `db:"-"`
+}
+
+// sessionTTL is how long a session lives after it was last used (SESSION_TTL_HOURS).
+func sessionTTL() time.Duration {
+	if s := strings.TrimSpace(os.Getenv("SESSION_TTL_HOURS")); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return time.Duration(n) * time.Hour
+		}
+	}
+	return defaultSessionTTL
+}
+
+// lookupSession resolves a token to its unexpired session and slides the
+// expiry forward when the session is in use.
+func lookupSession(db *sqlx.DB, token string) (activeSession, bool) {
+	if db == nil || token == "" {
+		return activeSession{}, false
+	}
+
+	var s activeSession
+	q := db.Rebind(`
+		SELECT s.id, s.user_id, u.role, s.last_seen_at, COALESCE(s.impersonator_id, 0) AS impersonator_id
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token = ? AND s.expires_at > NOW()
+		LIMIT 1
+	`)
+	if err := db.Get(&s, q, token); err != nil {
+		return activeSession{}, false
+	}
+
+	// Impersonation sessions keep their hard expiry.
+	if s.ImpersonatorID == 0 && time.Since(s.LastSeenAt) > sessionTouchInterval {
+		q2 := db.Rebind(`
+			UPDATE sessions
+			SET last_seen_at = NOW(),
+			    expires_at = NOW() + (? * INTERVAL '1 second')
+			WHERE id = ?
+		`)
+		if _, err := db.Exec(q2, int(sessionTTL()/time.Second), s.ID); err == nil {
+			s.Renewed = true
+		}
+	}
+	return s, true
+}
+
+// recordSessionStart stamps a freshly created session with its expiry and
+// the device it was created from. go-auth only inserts user_id and token.
+func recordSessionStart(c echo.Context, token string) {
+	db, err := ConnectDB()
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	ua := truncateRunes(c.Request().UserAgent(), 512)
+
+	q := db.Rebind(`
+		UPDATE sessions
+		SET expires_at = NOW() + (? * INTERVAL '1 second'),
+		    last_seen_at = NOW(),
+		    user_agent = ?,
+		    ip = ?
+		WHERE token = ?
+	`)
+	_, _ = db.Exec(q, int(sessionTTL()/time.Second), ua, c.RealIP(), token)
+}
+
+// StartSessionJanitor deletes expired sessions and old stored scan decisions
+// every interval until the process exits.
+func StartSessionJanitor(db *sqlx.DB, every time.Duration) {
+	purge := func() {
+		res, err := db.Exec(`DELETE FROM sessions WHERE expires_at <= NOW()`)
+		if err != nil {
+			log.Println("session janitor:", err)
+			return
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			log.Printf("session janitor: purged %d expired sessions", n)
+		}
+		q := db.Rebind(`DELETE FROM scan_requests WHERE created_at <= NOW() - (? * INTERVAL '1 second')`)
+		if _, err := db.Exec(q, int(scanRequestRetention/time.Second)); err != nil {
+			log.Println("session janitor: scan requests:", err)
+		}
+	}
+
+	go func() {
+		purge()
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for range t.C {
+			purge()
+		}
+	}()
+}
+
+// truncateRunes cuts s to at most n characters, never through the middle
+// of one, so the result is still valid UTF-8.
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
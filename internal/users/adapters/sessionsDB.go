@@ -6,6 +6,9 @@ import (
 	"log"
 )
 
+// SessionsStore backs go-auth's session lookups. It only ever serves the
+// sessions table, so reads skip expired rows and tolerate the extra
+// lifecycle columns go-auth's Session struct doesn't know about.
 type SessionsStore[Item any] struct {
 	db *sqlx.DB
 }
@@ -30,7 +33,7 @@ func (s *SessionsStore[Item]) Insert(item Item, sql string) error {
 
 func (s *SessionsStore[Item]) Get(id, table string) (Item, error) {
 	var item Item
-	err := s.db.Get(&item, "SELECT * FROM "+table+" WHERE id = ?", id)
+	err := s.db.Unsafe().Get(&item, s.db.Rebind("SELECT * FROM "+table+" WHERE id = ? AND expires_at > NOW()"), id)
 	if err != nil {
 		return item, err
 	}
@@ -39,7 +42,7 @@ func (s *SessionsStore[Item]) Get(id, table string) (Item, error) {
 
 func (s *SessionsStore[Item]) GetAll(table string) []Item {
 	var items []Item
-	err := s.db.Select(&items, "SELECT * FROM "+table+" ORDER BY id DESC")
+	err := s.db.Unsafe().Select(&items, "SELECT * FROM "+table+" WHERE expires_at > NOW() ORDER BY id DESC")
 
 	if err != nil {
 		log.Fatal(err)
@@ -49,19 +52,19 @@ func (s *SessionsStore[Item]) GetAll(table string) []Item {
 }
 
 func (s *SessionsStore[Item]) Delete(id, table string) error {
-	_, err := s.db.Exec("DELETE FROM "+table+" WHERE id = ?", id)
+	_, err := s.db.Exec(s.db.Rebind("DELETE FROM "+table+" WHERE id = ?"), id)
 	return err
 }
 
 func (s *SessionsStore[Item]) DeleteByField(field, value, table string) error {
-	_, err := s.db.Exec("DELETE FROM "+table+" WHERE "+field+" = ?", value)
+	_, err := s.db.Exec(s.db.Rebind("DELETE FROM "+table+" WHERE "+field+" = ?"), value)
 
 	return err
 }
 
 func (s *SessionsStore[Item]) GetByField(field, value, table string) (Item, error) {
 	var item Item
-	err := s.db.Get(&item, "SELECT * FROM "+table+" WHERE "+field+" = ?", value)
+	err := s.db.Unsafe().Get(&item, s.db.Rebind("SELECT * FROM "+table+" WHERE "+field+" = ? AND expires_at > NOW()"), value)
 
 	if err != nil {
 		return item, err
@@ -0,0 +1,72 @@
+package adapters
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// startSubscriptionPeriod ends the subscription's open period on day, if it
+// has one, and opens the next on whatever plan and price the row now has.
+// Call it after the subscriptions row is updated.
+func startSubscriptionPeriod(db sqlx.Ext, subID, day, reason string) error {
+	if err := endSubscriptionPeriod(db, subID, day, reason); err != nil {
+		return err
+	}
+	q := db.Rebind(`
+		INSERT INTO subscription_periods (subscription_id, user_id, plan_id, price_cents, started_on, start_reason)
+		SELECT s.id, s.user_id, s.plan_id, COALESCE(s.price_cents, p.price_cents), ?, ?
+		FROM subscriptions s
+		JOIN plans p ON p.id = s.plan_id
+		WHERE s.id = ?
+	`)
+	_, err := db.Exec(q, day, reason, subID)
+	return err
+}
+
+// endSubscriptionPeriod closes the subscription's open period on day.
+func endSubscriptionPeriod(db sqlx.Ext, subID, day, reason string) error {
+	q := db.Rebind(`UPDATE subscription_periods SET ended_on = ?, end_reason = ? WHERE subscription_id = ? AND ended_on IS NULL`)
+	_, err := db.Exec(q, day, reason, subID)
+	return err
+}
+
+// subscriptionPeriodOut is one entry of a member's subscription timeline.
+type subscriptionPeriodOut struct {
+	ID             int64  `json:"id" db:"id"`
+	SubscriptionID string `json:"subscriptionId" db:"subscription_id"`
+	CarID          string `json:"carId,omitempty" db:"car_id"`
+	CarName        string `json:"carName,omitempty" db:"car_name"`
+	PlanID         string `json:"planId" db:"plan_id"`
+	PlanName       string `json:"planName" db:"plan_name"`
+	PriceCents     int    `json:"priceCents" db:"price_cents"`
+	StartedOn      string `json:"startedOn" db:"started_on"`
+	// EndedOn is empty for the period the subscription is in now.
+	EndedOn     string `json:"endedOn,omitempty" db:"ended_on"`
+	StartReason string `json:"startReason" db:"start_reason"`
+	EndReason   string `json:"endReason,omitempty" db:"end_reason"`
+	// CancelReason is the member's reason when the period ended in cancellation.
+	CancelReason string `json:"cancelReason,omitempty" db:"cancel_reason"`
+}
+
+// subscriptionTimeline lists every plan period the member has had, newest first.
+func subscriptionTimeline(db *sqlx.DB, uid int) ([]subscriptionPeriodOut, error) {
+	out := []subscriptionPeriodOut{}
+	err := db.Select(&out, db.Rebind(`
+		SELECT sp.id, sp.subscription_id,
+		       COALESCE(s.car_id, '') AS car_id,
+		       COALESCE(NULLIF(c.nickname, ''), TRIM(COALESCE(c.make, '') || ' ' || COALESCE(c.model, '')), '') AS car_name,
+		       sp.plan_id,
+		       COALESCE(p.name, sp.plan_id) AS plan_name,
+		       sp.price_cents, sp.started_on,
+		       COALESCE(sp.ended_on, '') AS ended_on,
+		       sp.start_reason,
+		       COALESCE(sp.end_reason, '') AS end_reason,
+		       CASE WHEN sp.end_reason = 'canceled' THEN COALESCE(s.cancel_reason, '') ELSE '' END AS cancel_reason
+		FROM subscription_periods sp
+		JOIN subscriptions s ON s.id = sp.subscription_id
+		LEFT JOIN plans p ON p.id = sp.plan_id
+		LEFT JOIN cars c ON c.id = s.car_id
+		WHERE sp.user_id = ?
+		ORDER BY sp.started_on DESC, sp.id DESC
+	`), uid)
+	return out, err
+}
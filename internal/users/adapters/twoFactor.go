@@ -0,0 +1,370 @@
+package adapters
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod          = 30
+	totpSkew            = 1
+	recoveryCodeCount   = 10
+	twoFactorChallenge  = 5 * time.Minute
+	twoFactorMaxAttempt = 5
+)
+
+var (
+	errTwoFactorCode       = errors.New("Invalid authentication code")
+	errTwoFactorEnabled    = errors.New("Two-factor authentication is already enabled")
+	errTwoFactorNotEnabled = errors.New("Two-factor authentication is not enabled")
+	errTwoFactorChallenge  = errors.New("Sign-in expired, please log in again")
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Skew:      totpSkew,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// TwoFactor handles TOTP enrollment, recovery codes and the second step of
+// sign-in. Secrets and codes never leave this type except at enrollment.
+type TwoFactor struct {
+	db     *sqlx.DB
+	issuer string
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	// QR is a PNG data URL of URI, ready for an <img src>.
+	QR string `json:"qr"`
+}
+
+func NewTwoFactor(db *sqlx.DB) *TwoFactor {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Hedgestone Carwash"
+	}
+	return &TwoFactor{db: db, issuer: issuer}
+}
+
+// twoFactorPending reports whether the user's role requires 2FA and they
+// haven't enrolled yet.
+func twoFactorPending(db *sqlx.DB, uid int64, role string) bool {
+	var pending bool
+	q := db.Rebind(`
+		SELECT r.require_2fa AND NOT EXISTS (
+			SELECT 1 FROM user_totp t WHERE t.user_id = ? AND t.enabled_at IS NOT NULL
+		)
+		FROM roles r
+		WHERE r.id = ?
+	`)
+	if err := db.Get(&pending, q, uid, role); err != nil {
+		return false
+	}
+	return pending
+}
+
+func (t *TwoFactor) Enabled(uid int64) bool {
+	var n int
+	q := t.db.Rebind(`SELECT COUNT(1) FROM user_totp WHERE user_id = ? AND enabled_at IS NOT NULL`)
+	return t.db.Get(&n, q, uid) == nil && n > 0
+}
+
+func (t *TwoFactor) Status(uid int64) (TwoFactorStatus, error) {
+	var st TwoFactorStatus
+	st.Enabled = t.Enabled(uid)
+	var role string
+	if err := t.db.Get(&role, t.db.Rebind(`SELECT role FROM users WHERE id = ? LIMIT 1`), uid); err != nil {
+		return st, err
+	}
+	_ = t.db.Get(&st.Required, t.db.Rebind(`SELECT require_2fa FROM roles WHERE id = ?`), role)
+	_ = t.db.Get(&st.RecoveryCodesLeft, t.db.Rebind(`SELECT COUNT(1) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL`), uid)
+	return st, nil
+}
+
+// audit records a 2FA event for uid in the admin audit log.
+func (t *TwoFactor) audit(actorID, uid int64, action string, detail map[string]any) {
+	writeAudit(t.db, actorID, action, "user", strconv.FormatInt(uid, 10), detail)
+}
+
+// BeginEnrollment returns the pending secret for uid, creating one if
+// needed, so reloading the setup page shows the same QR code.
+func (t *TwoFactor) BeginEnrollment(uid int64) (TwoFactorEnrollment, error) {
+	if t.Enabled(uid) {
+		return TwoFactorEnrollment{}, errTwoFactorEnabled
+	}
+
+	var account string
+	_ = t.db.Get(&account, t.db.Rebind(`SELECT COALESCE(NULLIF(email,''), username) FROM users WHERE id = ? LIMIT 1`), uid)
+
+	var secret string
+	err := t.db.Get(&secret, t.db.Rebind(`SELECT secret FROM user_totp WHERE user_id = ? AND enabled_at IS NULL`), uid)
+	if err != nil {
+		key, err := totp.Generate(totp.GenerateOpts{Issuer: t.issuer, AccountName: account})
+		if err != nil {
+			return TwoFactorEnrollment{}, err
+		}
+		secret = key.Secret()
+		if _, err := t.db.Exec(t.db.Rebind(`
+			INSERT INTO user_totp (user_id, secret) VALUES (?, ?)
+			ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		`), uid, secret); err != nil {
+			return TwoFactorEnrollment{}, err
+		}
+	}
+
+	return t.enrollmentFor(secret, account)
+}
+
+func (t *TwoFactor) enrollmentFor(secret, account string) (TwoFactorEnrollment, error) {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", t.issuer)
+	v.Set("period", "30")
+	v.Set("digits", "6")
+	v.Set("algorithm", "SHA1")
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + t.issuer + ":" + account,
+		RawQuery: v.Encode(),
+	}
+	key, err := otp.NewKeyFromURL(u.String())
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	img, err := key.Image(240, 240)
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	return TwoFactorEnrollment{
+		Secret: secret,
+		URI:    key.URL(),
+		QR:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// ConfirmEnrollment enables 2FA once the user proves their app produces
+// valid codes, and returns a fresh set of recovery codes.
+func (t *TwoFactor) ConfirmEnrollment(uid int64, code string) ([]string, error) {
+	var secret string
+	if err := t.db.Get(&secret, t.db.Rebind(`SELECT secret FROM user_totp WHERE user_id = ? AND enabled_at IS NULL`), uid); err != nil {
+		if t.Enabled(uid) {
+			return nil, errTwoFactorEnabled
+		}
+		return nil, errTwoFactorNotEnabled
+	}
+	step, ok := matchTOTP(secret, code, time.Now().UTC())
+	if !ok {
+		return nil, errTwoFactorCode
+	}
+
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(tx.Rebind(`UPDATE user_totp SET enabled_at = NOW(), last_used_step = ? WHERE user_id = ?`), step, uid); err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(tx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return codes, tx.Commit()
+}
+
+// Disable removes the user's TOTP secret and recovery codes.
+func (t *TwoFactor) Disable(uid int64) error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(tx.Rebind(`DELETE FROM user_totp WHERE user_id = ?`), uid); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(tx.Rebind(`DELETE FROM user_recovery_codes WHERE user_id = ?`), uid); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (t *TwoFactor) RegenerateRecoveryCodes(uid int64) ([]string, error) {
+	if !t.Enabled(uid) {
+		return nil, errTwoFactorNotEnabled
+	}
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(tx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return codes, tx.Commit()
+}
+
+// Verify accepts a current TOTP code or an unused recovery code.
+// usedRecovery is true when a recovery code was burned.
+func (t *TwoFactor) Verify(uid int64, code string) (usedRecovery bool, err error) {
+	code = strings.TrimSpace(code)
+	var row struct {
+		Secret   string `db:"secret"`
+		LastStep int64  `db:"last_used_step"`
+	}
+	if err := t.db.Get(&row, t.db.Rebind(`SELECT secret, last_used_step FROM user_totp WHERE user_id = ? AND enabled_at IS NOT NULL`), uid); err != nil {
+		return false, errTwoFactorNotEnabled
+	}
+
+	if step, ok := matchTOTP(row.Secret, code, time.Now().UTC()); ok {
+		// Each code is good once; the conditional update is the replay guard.
+		res, err := t.db.Exec(t.db.Rebind(`UPDATE user_totp SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?`), step, uid, step)
+		if err != nil {
+			return false, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return false, errTwoFactorCode
+		}
+		return false, nil
+	}
+
+	res, err := t.db.Exec(t.db.Rebind(`
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`), uid, hashAccountToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, errTwoFactorCode
+	}
+	return true, nil
+}
+
+// StartChallenge records that uid passed the password step and returns the
+// token the client presents with its code.
+func (t *TwoFactor) StartChallenge(uid int64) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	_, _ = t.db.Exec(`DELETE FROM two_factor_challenges WHERE expires_at < NOW()`)
+	_, err := t.db.Exec(t.db.Rebind(`
+		INSERT INTO two_factor_challenges (token_hash, user_id, expires_at) VALUES (?, ?, ?)
+	`), hashAccountToken(token), uid, time.Now().UTC().Add(twoFactorChallenge))
+	return token, err
+}
+
+// challengeUser returns who a live challenge belongs to, or 0, so the
+// login throttle can be checked before the code is.
+func (t *TwoFactor) challengeUser(token string) int64 {
+	var uid int64
+	q := t.db.Rebind(`SELECT user_id FROM two_factor_challenges WHERE token_hash = ? AND expires_at > NOW()`)
+	_ = t.db.Get(&uid, q, hashAccountToken(strings.TrimSpace(token)))
+	return uid
+}
+
+// CompleteChallenge checks code against the challenge's user. The challenge
+// is consumed on success and dropped after too many wrong codes.
+func (t *TwoFactor) CompleteChallenge(token, code string) (uid int64, usedRecovery bool, err error) {
+	h := hashAccountToken(strings.TrimSpace(token))
+	var row struct {
+		UserID   int64 `db:"user_id"`
+		Attempts int   `db:"attempts"`
+	}
+	q := t.db.Rebind(`SELECT user_id, attempts FROM two_factor_challenges WHERE token_hash = ? AND expires_at > NOW()`)
+	if err := t.db.Get(&row, q, h); err != nil || row.Attempts >= twoFactorMaxAttempt {
+		return 0, false, errTwoFactorChallenge
+	}
+
+	usedRecovery, err = t.Verify(row.UserID, code)
+	if err != nil {
+		_, _ = t.db.Exec(t.db.Rebind(`UPDATE two_factor_challenges SET attempts = attempts + 1 WHERE token_hash = ?`), h)
+		return 0, false, err
+	}
+	res, err := t.db.Exec(t.db.Rebind(`DELETE FROM two_factor_challenges WHERE token_hash = ?`), h)
+	if err != nil {
+		return 0, false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, false, errTwoFactorChallenge
+	}
+	return row.UserID, usedRecovery, nil
+}
+
+// matchTOTP returns the time step code was generated for, within the
+// allowed skew.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != 6 {
+		return 0, false
+	}
+	for off := -totpSkew; off <= totpSkew; off++ {
+		at := now.Add(time.Duration(off*totpPeriod) * time.Second)
+		want, err := totp.GenerateCodeCustom(secret, at, totpOpts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return at.Unix() / totpPeriod, true
+		}
+	}
+	return 0, false
+}
+
+func replaceRecoveryCodes(tx *sqlx.Tx, uid int64) ([]string, error) {
+	if _, err := tx.Exec(tx.Rebind(`DELETE FROM user_recovery_codes WHERE user_id = ?`), uid); err != nil {
+		return nil, err
+	}
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(buf))[:10]
+		if _, err := tx.Exec(tx.Rebind(`INSERT INTO user_recovery_codes (code_hash, user_id) VALUES (?, ?)`), hashAccountToken(raw), uid); err != nil {
+			return nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -1,13 +1,10 @@
 package adapters
 
 import (
-	"golang.org/x/crypto/bcrypt"
 	"log"
-	"net/http"
 	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	auth "github.com/edlingao/go-auth/auth/core"
 	"github.com/edlingao/hexago/internal/users/core"
@@ -109,6 +106,9 @@ type UsersAPIService struct {
 	httpService    *echo.Group
 	sessionService auth.SessionService
 	usersService   ports.UserServiceMethods
+	recovery       *AccountRecovery
+	twoFactor      *TwoFactor
+	throttle       *LoginThrottle
 	secret         string
 }
 
@@ -117,6 +117,13 @@ type SignInResponse struct {
 	Token string    `json:"token"`
 }
 
+// TwoFactorRequiredResponse is returned by SignIn instead of a session when
+// the account has 2FA; post the challenge and a code to /signin/2fa.
+type TwoFactorRequiredResponse struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	Challenge         string `json:"challenge"`
+}
+
 func NewUsersAPIService(
 	dbService ports.StoringUsers,
 	httpService *echo.Group,
@@ -135,16 +142,33 @@ func NewUsersAPIService(
 	}
 
 	uApiService.httpService.POST("/signin", uApiService.SignIn)
+	uApiService.httpService.POST("/signin/2fa", uApiService.SignInTwoFactor)
 	uApiService.httpService.POST("/signup", uApiService.SignUp)
 	uApiService.httpService.POST("/logout", uApiService.Logout)
 
 	// Protected routes
-	protected := uApiService.httpService.Group("", sessionService.APIAuth)
+	protected := uApiService.httpService.Group("", RequireAuth)
 	protected.GET("/all", uApiService.GetAllUsers)
 
 	return uApiService
 }
 
+// WithAccountRecovery enables the verification email sent on sign-up.
+func (uas *UsersAPIService) WithAccountRecovery(recovery *AccountRecovery) *UsersAPIService {
+	uas.recovery = recovery
+	return uas
+}
+
+func (uas *UsersAPIService) WithTwoFactor(twoFactor *TwoFactor) *UsersAPIService {
+	uas.twoFactor = twoFactor
+	return uas
+}
+
+func (uas *UsersAPIService) WithLoginThrottle(throttle *LoginThrottle) *UsersAPIService {
+	uas.throttle = throttle
+	return uas
+}
+
 func (uas *UsersAPIService) GetAllUsers(c echo.Context) error {
 	users := uas.dbService.GetAll("users")
 
@@ -168,78 +192,100 @@ func (uas *UsersAPIService) SignIn(c echo.Context) error {
 
 	uname := resolveUsername(identifier)
 
-	// First try the existing user service
-	user, err := uas.usersService.SignIn(uname, password)
-
-	// Fallback for demo seeds: support plaintext passwords (and bcrypt if present)
-	if err != nil {
-		db, derr := ConnectDB()
-		if derr == nil {
-			defer db.Close()
-
-			var row struct {
-				ID        int    `db:"id"`
-				Username  string `db:"username"`
-				Password  string `db:"password"`
-				Email     string `db:"email"`
-				FirstName string `db:"first_name"`
-				LastName  string `db:"last_name"`
-				AvatarURL string `db:"avatar_url"`
-			}
-
-			qerr := db.Get(&row, db.Rebind(`SELECT id, username, password, email, first_name, last_name, avatar_url FROM users WHERE username = ? LIMIT 1`), uname)
-			if qerr == nil {
-				ok := false
-				if strings.HasPrefix(row.Password, "$2a$") || strings.HasPrefix(row.Password, "$2b$") || strings.HasPrefix(row.Password, "$2y$") {
-					ok = bcrypt.CompareHashAndPassword([]byte(row.Password), []byte(password)) == nil
-				} else {
-					ok = row.Password == password
-				}
-
-				if ok {
-					user = core.User{
-						ID:        strconv.Itoa(row.ID),
-						Username:  row.Username,
-						Email:     row.Email,
-						FirstName: row.FirstName,
-						LastName:  row.LastName,
-						AvatarURL: row.AvatarURL,
-					}
-					err = nil
-				}
-			}
+	var lockUID int64
+	if uas.throttle != nil {
+		lockUID = uas.throttle.userIDFor(uname)
+		if wait := uas.throttle.RetryAfter(lockUID, c.RealIP()); wait > 0 {
+			setRetryAfter(c, wait)
+			return c.JSON(429, ports.Response[any]{Status: 429, Message: lockoutMessage(wait)})
 		}
 	}
 
+	user, err := uas.usersService.SignIn(uname, password)
 	if err != nil {
+		if uas.throttle != nil {
+			uas.throttle.RecordFailure(lockUID, c.RealIP())
+		}
 		return c.JSON(401, ports.Response[any]{
 			Status:  401,
 			Message: "Invalid username or password",
 		})
 	}
 
+	// The account's failure count is only cleared once sign-in is complete,
+	// so a known password doesn't buy unlimited guesses at the 2FA code.
+	uid, _ := strconv.ParseInt(user.ID, 10, 64)
+	if uas.twoFactor != nil && uas.twoFactor.Enabled(uid) {
+		challenge, err := uas.twoFactor.StartChallenge(uid)
+		if err != nil {
+			return c.JSON(500, ports.Response[any]{Status: 500, Message: err.Error()})
+		}
+		return c.JSON(200, ports.Response[TwoFactorRequiredResponse]{
+			Status:  200,
+			Message: "Two-factor code required",
+			Data:    TwoFactorRequiredResponse{TwoFactorRequired: true, Challenge: challenge},
+		})
+	}
+	if uas.throttle != nil {
+		uas.throttle.RecordSuccess(lockUID)
+	}
+
+	return uas.startSession(c, user)
+}
+
+// SignInTwoFactor completes a sign-in started by SignIn with a TOTP or
+// recovery code.
+func (uas *UsersAPIService) SignInTwoFactor(c echo.Context) error {
+	if uas.twoFactor == nil {
+		return c.JSON(404, ports.Response[any]{Status: 404, Message: "Not found"})
+	}
+	challenge := strings.TrimSpace(c.FormValue("challenge"))
+	code := strings.TrimSpace(c.FormValue("code"))
+	if challenge == "" || code == "" {
+		return c.JSON(400, ports.Response[any]{Status: 400, Message: "Challenge and code are required"})
+	}
+
+	lockUID := uas.twoFactor.challengeUser(challenge)
+	if uas.throttle != nil {
+		if wait := uas.throttle.RetryAfter(lockUID, c.RealIP()); wait > 0 {
+			setRetryAfter(c, wait)
+			return c.JSON(429, ports.Response[any]{Status: 429, Message: lockoutMessage(wait)})
+		}
+	}
+
+	uid, usedRecovery, err := uas.twoFactor.CompleteChallenge(challenge, code)
+	if err != nil {
+		if uas.throttle != nil {
+			uas.throttle.RecordFailure(lockUID, c.RealIP())
+		}
+		return c.JSON(401, ports.Response[any]{Status: 401, Message: err.Error()})
+	}
+	if uas.throttle != nil {
+		uas.throttle.RecordSuccess(uid)
+	}
+	if usedRecovery {
+		uas.twoFactor.audit(uid, uid, "2fa.recovery_used", map[string]any{})
+	}
+
+	user, err := uas.usersService.Get(strconv.FormatInt(uid, 10))
+	if err != nil {
+		return c.JSON(500, ports.Response[any]{Status: 500, Message: err.Error()})
+	}
+	return uas.startSession(c, user)
+}
+
+func (uas *UsersAPIService) startSession(c echo.Context, user core.User) error {
 	token, err := uas.sessionService.Create(user.ID, user.Username, uas.secret)
 	if err != nil {
-		log.Println(user.ID, user.Username, uas.secret, err)
+		log.Println(user.ID, user.Username, err)
 		return c.JSON(500, ports.Response[any]{
 			Status:  500,
 			Message: err.Error(),
 		})
 	}
 
-	// Set session token cookie (HttpOnly)
-	cookie := new(http.Cookie)
-	cookie.Name = "session_token"
-	cookie.Value = token.Token
-	cookie.Path = "/"
-	cookie.HttpOnly = true
-	cookie.SameSite = http.SameSiteLaxMode
-	cookie.Expires = time.Now().Add(7 * 24 * time.Hour)
-	cookie.MaxAge = 60 * 60 * 24 * 7
-	if os.Getenv("ENV") == "production" {
-		cookie.Secure = true
-	}
-	c.SetCookie(cookie)
+	recordSessionStart(c, token.Token)
+	setSessionCookie(c, token.Token)
 
 	return c.JSON(200, ports.Response[SignInResponse]{
 		Status:  200,
@@ -266,7 +312,12 @@ func (uas *UsersAPIService) SignUp(c echo.Context) error {
 		return c.JSON(400, ports.Response[any]{Status: 400, Message: "Invalid email"})
 	}
 
-	user, err := insertUser(username, password, email, firstName, lastName, avatarUrl)
+	hash, err := uas.usersService.HashPassword(password)
+	if err != nil {
+		return c.JSON(400, ports.Response[any]{Status: 400, Message: "Invalid password"})
+	}
+
+	user, err := insertUser(username, hash, email, firstName, lastName, avatarUrl)
 	if err != nil {
 		// Handle 409 from echo HTTPError
 		if he, ok := err.(*echo.HTTPError); ok {
@@ -280,24 +331,20 @@ func (uas *UsersAPIService) SignUp(c echo.Context) error {
 		return c.JSON(500, ports.Response[any]{Status: 500, Message: err.Error()})
 	}
 
+	if email != "" && uas.recovery != nil {
+		uid, _ := strconv.ParseInt(user.ID, 10, 64)
+		if err := uas.recovery.SendVerification(uid); err != nil {
+			log.Println("signup verification mail:", err)
+		}
+	}
+
 	token, err := uas.sessionService.Create(user.ID, user.Username, uas.secret)
 	if err != nil {
 		return c.JSON(500, ports.Response[any]{Status: 500, Message: err.Error()})
 	}
 
-	// Set session token cookie (HttpOnly)
-	cookie := new(http.Cookie)
-	cookie.Name = "session_token"
-	cookie.Value = token.Token
-	cookie.Path = "/"
-	cookie.HttpOnly = true
-	cookie.SameSite = http.SameSiteLaxMode
-	cookie.Expires = time.Now().Add(7 * 24 * time.Hour)
-	cookie.MaxAge = 60 * 60 * 24 * 7
-	if os.Getenv("ENV") == "production" {
-		cookie.Secure = true
-	}
-	c.SetCookie(cookie)
+	recordSessionStart(c, token.Token)
+	setSessionCookie(c, token.Token)
 
 	return c.JSON(200, ports.Response[SignInResponse]{
 		Status:  200,
@@ -310,20 +357,7 @@ func (uas *UsersAPIService) SignUp(c echo.Context) error {
 }
 
 func (uas *UsersAPIService) Logout(c echo.Context) error {
-	// token from cookie session_token, Authorization: Bearer, or X-Session-Token
-	token := ""
-	if ck, err := c.Cookie("session_token"); err == nil {
-		token = ck.Value
-	}
-	if token == "" {
-		authz := c.Request().Header.Get("Authorization")
-		if strings.HasPrefix(authz, "Bearer ") {
-			token = strings.TrimSpace(strings.TrimPrefix(authz, "Bearer "))
-		}
-	}
-	if token == "" {
-		token = strings.TrimSpace(c.Request().Header.Get("X-Session-Token"))
-	}
+	token, _ := sessionTokenFromRequest(c)
 
 	// Best-effort delete session row
 	if token != "" {
@@ -334,18 +368,7 @@ func (uas *UsersAPIService) Logout(c echo.Context) error {
 		}
 	}
 
-	// Expire cookie
-	secure := os.Getenv("ENV") == "production"
-	c.SetCookie(&http.Cookie{
-		Name:     "session_token",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		Expires:  time.Unix(0, 0),
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-		Secure:   secure,
-	})
+	clearSessionCookies(c)
 
 	return c.JSON(200, map[string]any{"ok": true})
 }
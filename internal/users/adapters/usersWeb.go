@@ -4,17 +4,18 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	authCore "github.com/edlingao/go-auth/auth/core"
 	"github.com/edlingao/hexago/common/delivery/web"
+	"github.com/edlingao/hexago/internal/users/core"
 	"github.com/edlingao/hexago/internal/users/ports"
 	"github.com/edlingao/hexago/web/views/admin"
 	"github.com/edlingao/hexago/web/views/auth"
 	"github.com/edlingao/hexago/web/views/scanner"
 	"github.com/edlingao/hexago/web/views/users"
 	"github.com/labstack/echo/v4"
-	"strings"
 )
 
 type UsersWebService struct {
@@ -23,8 +24,14 @@ type UsersWebService struct {
 	sessionService authCore.SessionService
 	usersService   ports.UserServiceMethods
 	dbService      ports.StoringUsers
+	twoFactor      *TwoFactor
+	throttle       *LoginThrottle
 }
 
+// twoFactorChallengeCookie carries the pending sign-in between the password
+// form and the code form.
+const twoFactorChallengeCookie = "two_factor_challenge"
+
 func NewUsersWebService(
 	url string,
 	httpService *echo.Group,
@@ -45,6 +52,10 @@ func NewUsersWebService(
 	usersWebService.http.GET("/signup", usersWebService.SignUp)
 	usersWebService.http.POST("/login", usersWebService.LoginEndpoint)
 	usersWebService.http.POST("/register", usersWebService.SignUpEndpoint)
+	usersWebService.http.GET("/login/2fa", usersWebService.TwoFactorView)
+	usersWebService.http.POST("/login/2fa", usersWebService.TwoFactorEndpoint)
+	usersWebService.http.GET("/account/two-factor", usersWebService.TwoFactorSetupView)
+	usersWebService.http.POST("/account/two-factor", usersWebService.TwoFactorSetupEndpoint)
 
 	// Mock views (public for development - uses Alpine.js localStorage auth)
 	usersWebService.http.GET("/dashboard", usersWebService.Dashboard)
@@ -56,12 +67,22 @@ func NewUsersWebService(
 	usersWebService.http.GET("/admin/portal", usersWebService.AdminPortal)
 
 	// Protected routes
-	protectedAPI := usersWebService.http.Group("", sessionService.APIAuth)
+	protectedAPI := usersWebService.http.Group("", RequireAuth)
 	protectedAPI.GET("/all", usersWebService.GetAllUsers)
 
 	return usersWebService
 }
 
+func (uws *UsersWebService) WithTwoFactor(twoFactor *TwoFactor) *UsersWebService {
+	uws.twoFactor = twoFactor
+	return uws
+}
+
+func (uws *UsersWebService) WithLoginThrottle(throttle *LoginThrottle) *UsersWebService {
+	uws.throttle = throttle
+	return uws
+}
+
 func (uws *UsersWebService) GetAllUsers(c echo.Context) error {
 	return nil
 }
@@ -86,9 +107,27 @@ func (uws *UsersWebService) LoginEndpoint(c echo.Context) error {
 	username := c.FormValue("username")
 	password := c.FormValue("password")
 
+	var lockUID int64
+	if uws.throttle != nil {
+		lockUID = uws.throttle.userIDFor(username)
+		if wait := uws.throttle.RetryAfter(lockUID, c.RealIP()); wait > 0 {
+			setRetryAfter(c, wait)
+			return web.Render(
+				c,
+				auth.SignIn(auth.SignInVM{
+					Error: errors.New(lockoutMessage(wait)),
+				}),
+				429,
+			)
+		}
+	}
+
 	user, err := uws.usersService.SignIn(username, password)
 
 	if err != nil {
+		if uws.throttle != nil {
+			uws.throttle.RecordFailure(lockUID, c.RealIP())
+		}
 		return web.Render(
 			c,
 			auth.SignIn(auth.SignInVM{
@@ -98,6 +137,30 @@ func (uws *UsersWebService) LoginEndpoint(c echo.Context) error {
 		)
 	}
 
+	// The account's failure count is only cleared once sign-in is complete,
+	// so a known password doesn't buy unlimited guesses at the 2FA code.
+	uid, _ := strconv.ParseInt(user.ID, 10, 64)
+	if uws.twoFactor != nil && uws.twoFactor.Enabled(uid) {
+		challenge, err := uws.twoFactor.StartChallenge(uid)
+		if err != nil {
+			return web.Render(c, auth.SignIn(auth.SignInVM{Error: err}), 500)
+		}
+		c.SetCookie(&http.Cookie{
+			Name:     twoFactorChallengeCookie,
+			Value:    challenge,
+			Path:     "/login/2fa",
+			MaxAge:   int(twoFactorChallenge / time.Second),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   os.Getenv("ENV") == "production",
+		})
+		c.Response().Header().Set("HX-Location", "/login/2fa")
+		return web.Render(c, auth.SignIn(auth.SignInVM{}), 200)
+	}
+	if uws.throttle != nil {
+		uws.throttle.RecordSuccess(lockUID)
+	}
+
 	token, err := uws.sessionService.Create(user.ID, user.Username, os.Getenv("JWT_SECRET"))
 
 	if err != nil {
@@ -109,8 +172,8 @@ func (uws *UsersWebService) LoginEndpoint(c echo.Context) error {
 			500,
 		)
 	}
-	cookie := uws.SetCookie(token.Token, c)
-	c.SetCookie(cookie)
+	recordSessionStart(c, token.Token)
+	setSessionCookie(c, token.Token)
 	c.Response().Header().Set("HX-Location", "/dashboard")
 
 	return web.Render(
@@ -166,8 +229,8 @@ func (uws *UsersWebService) SignUpEndpoint(c echo.Context) error {
 		)
 	}
 
-	cookie := uws.SetCookie(token.Token, c)
-	c.SetCookie(cookie)
+	recordSessionStart(c, token.Token)
+	setSessionCookie(c, token.Token)
 
 	c.Response().Header().Set("HX-Location", "/dashboard")
 
@@ -179,64 +242,6 @@ func (uws *UsersWebService) SignUpEndpoint(c echo.Context) error {
 
 }
 
-func (uws *UsersWebService) requireStaffRole(c echo.Context) (bool, int64) {
-	db, err := ConnectDB()
-	if err != nil {
-		return false, 0
-	}
-	defer db.Close()
-
-	// token from cookie session_token, Authorization: Bearer, or X-Session-Token
-	token := ""
-	if ck, err := c.Cookie("session_token"); err == nil {
-		token = ck.Value
-	}
-	if token == "" {
-		auth := c.Request().Header.Get("Authorization")
-		if strings.HasPrefix(auth, "Bearer ") {
-			token = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
-		}
-	}
-	if token == "" {
-		token = strings.TrimSpace(c.Request().Header.Get("X-Session-Token"))
-	}
-	if token == "" {
-		return false, 0
-	}
-
-	var uid int64
-	q := db.Rebind(`SELECT user_id FROM sessions WHERE token = ? LIMIT 1`)
-	if err := db.Get(&uid, q, token); err != nil {
-		return false, 0
-	}
-
-	var role string
-	q2 := db.Rebind(`SELECT role FROM users WHERE id = ? LIMIT 1`)
-	if err := db.Get(&role, q2, uid); err != nil {
-		return false, 0
-	}
-
-	if role != "admin" && role != "attendant" {
-		return false, uid
-	}
-	return true, uid
-}
-
-func (uh UsersWebService) SetCookie(key string, c echo.Context) *http.Cookie {
-	secure := os.Getenv("ENVIRONMENT") == "prod"
-	cookie := &http.Cookie{
-		Name:     "Auth",
-		Value:    key,
-		Expires:  time.Now().Add(24 * time.Hour),
-		HttpOnly: true,
-		Secure:   secure, // Set to true in production with HTTPS
-		SameSite: http.SameSiteLaxMode,
-		Path:     "/",
-	}
-
-	return cookie
-}
-
 func (uws *UsersWebService) Dashboard(c echo.Context) error {
 	return web.Render(
 		c,
@@ -255,11 +260,14 @@ func (uws *UsersWebService) QRCode(c echo.Context) error {
 
 func (uws *UsersWebService) Scanner(c echo.Context) error {
 
-	ok, uid := uws.requireStaffRole(c)
+	p, ok := principalFrom(c)
 	if !ok {
-		if uid == 0 {
-			return c.Redirect(302, "/login")
-		}
+		return c.Redirect(302, "/login")
+	}
+	if p.TwoFactorPending {
+		return c.Redirect(302, "/account/two-factor")
+	}
+	if !p.Can(core.PermScanPerform) {
 		return c.Redirect(302, "/dashboard")
 	}
 
@@ -297,3 +305,110 @@ func (uws *UsersWebService) Account(c echo.Context) error {
 func (uws *UsersWebService) ChoosePlan(c echo.Context) error {
 	return web.Render(c, users.ChoosePlan(users.ChoosePlanVM{}), 200)
 }
+
+func (uws *UsersWebService) TwoFactorView(c echo.Context) error {
+	if _, err := c.Cookie(twoFactorChallengeCookie); err != nil {
+		return c.Redirect(302, "/login")
+	}
+	return web.Render(c, auth.TwoFactorChallenge(auth.TwoFactorChallengeVM{}), 200)
+}
+
+func (uws *UsersWebService) TwoFactorEndpoint(c echo.Context) error {
+	ck, err := c.Cookie(twoFactorChallengeCookie)
+	if err != nil || uws.twoFactor == nil {
+		return c.Redirect(303, "/login")
+	}
+
+	lockUID := uws.twoFactor.challengeUser(ck.Value)
+	if uws.throttle != nil {
+		if wait := uws.throttle.RetryAfter(lockUID, c.RealIP()); wait > 0 {
+			setRetryAfter(c, wait)
+			return web.Render(c, auth.TwoFactorChallenge(auth.TwoFactorChallengeVM{Error: errors.New(lockoutMessage(wait))}), 429)
+		}
+	}
+
+	uid, usedRecovery, err := uws.twoFactor.CompleteChallenge(ck.Value, c.FormValue("code"))
+	if err != nil && uws.throttle != nil {
+		uws.throttle.RecordFailure(lockUID, c.RealIP())
+	}
+	if errors.Is(err, errTwoFactorChallenge) {
+		return c.Redirect(303, "/login")
+	}
+	if err != nil {
+		return web.Render(c, auth.TwoFactorChallenge(auth.TwoFactorChallengeVM{Error: err}), 400)
+	}
+	if uws.throttle != nil {
+		uws.throttle.RecordSuccess(uid)
+	}
+	if usedRecovery {
+		uws.twoFactor.audit(uid, uid, "2fa.recovery_used", map[string]any{})
+	}
+
+	user, err := uws.usersService.Get(strconv.FormatInt(uid, 10))
+	if err != nil {
+		return web.Render(c, auth.TwoFactorChallenge(auth.TwoFactorChallengeVM{Error: err}), 500)
+	}
+	token, err := uws.sessionService.Create(user.ID, user.Username, os.Getenv("JWT_SECRET"))
+	if err != nil {
+		return web.Render(c, auth.TwoFactorChallenge(auth.TwoFactorChallengeVM{Error: err}), 500)
+	}
+	recordSessionStart(c, token.Token)
+	setSessionCookie(c, token.Token)
+	c.SetCookie(&http.Cookie{Name: twoFactorChallengeCookie, Path: "/login/2fa", MaxAge: -1, HttpOnly: true})
+	return c.Redirect(303, "/dashboard")
+}
+
+func (uws *UsersWebService) twoFactorSetupVM(uid int64) (auth.TwoFactorSetupVM, error) {
+	st, err := uws.twoFactor.Status(uid)
+	if err != nil {
+		return auth.TwoFactorSetupVM{}, err
+	}
+	vm := auth.TwoFactorSetupVM{
+		Enabled:           st.Enabled,
+		Required:          st.Required,
+		RecoveryCodesLeft: st.RecoveryCodesLeft,
+	}
+	if !st.Enabled {
+		enr, err := uws.twoFactor.BeginEnrollment(uid)
+		if err != nil {
+			return vm, err
+		}
+		vm.Secret = enr.Secret
+		vm.QR = enr.QR
+	}
+	return vm, nil
+}
+
+func (uws *UsersWebService) TwoFactorSetupView(c echo.Context) error {
+	p, ok := principalFrom(c)
+	if !ok || uws.twoFactor == nil {
+		return c.Redirect(302, "/login")
+	}
+	vm, err := uws.twoFactorSetupVM(p.UserID)
+	if err != nil {
+		return web.Render(c, auth.TwoFactorSetup(auth.TwoFactorSetupVM{Error: err}), 500)
+	}
+	return web.Render(c, auth.TwoFactorSetup(vm), 200)
+}
+
+func (uws *UsersWebService) TwoFactorSetupEndpoint(c echo.Context) error {
+	p, ok := principalFrom(c)
+	if !ok || uws.twoFactor == nil {
+		return c.Redirect(303, "/login")
+	}
+	if p.ImpersonatorID > 0 {
+		return c.Redirect(303, "/account")
+	}
+
+	codes, err := uws.twoFactor.ConfirmEnrollment(p.UserID, c.FormValue("code"))
+	if err != nil {
+		vm, vmErr := uws.twoFactorSetupVM(p.UserID)
+		if vmErr != nil {
+			err = vmErr
+		}
+		vm.Error = err
+		return web.Render(c, auth.TwoFactorSetup(vm), 400)
+	}
+	uws.twoFactor.audit(p.UserID, p.UserID, "2fa.enroll", map[string]any{})
+	return web.Render(c, auth.TwoFactorSetup(auth.TwoFactorSetupVM{Enabled: true, RecoveryCodes: codes}), 200)
+}
@@ -0,0 +1,173 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// washResults are the wash_events.result values.
+var washResults = []string{"allowed", "denied", "allowed_override", "comp"}
+
+const (
+	washFeedBuffer    = 64
+	washFeedKeepAlive = 25 * time.Second
+)
+
+// WashEvent is a wash_events row as pushed to the live feed.
+type WashEvent struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"userId,omitempty"`
+	UserName   string    `json:"userName,omitempty"`
+	LocationID string    `json:"locationId,omitempty"`
+	ScannedAt  time.Time `json:"scannedAt"`
+	Result     string    `json:"result"`
+	Reason     string    `json:"reason,omitempty"`
+	Offline    bool      `json:"offline"`
+	// OverrideOf is the denied event an attendant let through.
+	OverrideOf string `json:"overrideOf,omitempty"`
+	ReasonCode string `json:"reasonCode,omitempty"`
+	Note       string `json:"note,omitempty"`
+	CarID      string `json:"carId,omitempty"`
+	PackageID  string `json:"packageId,omitempty"`
+	// UpchargeCents is what the member pays for PackageID on top of their plan.
+	UpchargeCents int `json:"upchargeCents,omitempty"`
+	scanSource
+
+	rawQR         string
+	clientEventID string
+}
+
+// scanSource is who or what made a scan: a signed-in attendant, a registered
+// device, and the lane it happened in.
+type scanSource struct {
+	ScannedBy int64  `json:"scannedBy,omitempty"`
+	DeviceID  string `json:"deviceId,omitempty"`
+	Lane      string `json:"lane,omitempty"`
+}
+
+// scanSourceFrom falls back to the device's lane when the request doesn't name one.
+func scanSourceFrom(c echo.Context, lane string) scanSource {
+	src := scanSource{Lane: strings.TrimSpace(lane)}
+	if d, ok := deviceFrom(c); ok {
+		src.DeviceID = d.ID
+		if src.Lane == "" {
+			src.Lane = d.Lane
+		}
+	} else if p, ok := principalFrom(c); ok {
+		src.ScannedBy = p.UserID
+	}
+	src.Lane = truncateRunes(src.Lane, 64)
+	return src
+}
+
+func newWashEvent(src scanSource, userID int, locationID, result, rawQR, reason string) WashEvent {
+	return WashEvent{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		LocationID: locationID,
+		ScannedAt:  time.Now().UTC().Truncate(time.Second),
+		Result:     result,
+		Reason:     reason,
+		scanSource: src,
+		rawQR:      rawQR,
+	}
+}
+
+// washEventHub fans recorded wash events out to live subscribers in this
+// process. Slow subscribers miss events rather than holding up scans.
+type washEventHub struct {
+	mu   sync.Mutex
+	subs map[chan WashEvent]struct{}
+}
+
+var washFeed = &washEventHub{subs: map[chan WashEvent]struct{}{}}
+
+func (h *washEventHub) subscribe() (<-chan WashEvent, func()) {
+	ch := make(chan WashEvent, washFeedBuffer)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+func (h *washEventHub) publish(ev WashEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// StreamWashEvents pushes wash events as Server-Sent Events. ?locationId and
+// ?result (allowed|denied|allowed_override|comp) narrow the feed; location scoping applies as in GetStats.
+func (a *AdminAPIService) StreamWashEvents(c echo.Context) error {
+	_, locs, ok := scopedLocations(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
+	}
+	result := strings.TrimSpace(c.QueryParam("result"))
+	if result != "" && !slices.Contains(washResults, result) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "result must be one of " + strings.Join(washResults, ", ")})
+	}
+
+	events, unsubscribe := washFeed.subscribe()
+	defer unsubscribe()
+
+	h := c.Response().Header()
+	h.Set(echo.HeaderContentType, "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	c.Response().WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprint(c.Response(), "retry: 5000\n\n"); err != nil {
+		return nil
+	}
+	c.Response().Flush()
+
+	keepAlive := time.NewTicker(washFeedKeepAlive)
+	defer keepAlive.Stop()
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Response(), ": ping\n\n"); err != nil {
+				return nil
+			}
+		case ev := <-events:
+			if locs != nil && !slices.Contains(locs, ev.LocationID) {
+				continue
+			}
+			if result != "" && ev.Result != result {
+				continue
+			}
+			if ev.UserID > 0 {
+				ev.UserName = scanUserDisplayName(a.db, ev.UserID)
+			}
+			b, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Response(), "id: %s\nevent: wash\ndata: %s\n\n", ev.ID, b); err != nil {
+				return nil
+			}
+		}
+		c.Response().Flush()
+	}
+}
@@ -0,0 +1,291 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edlingao/hexago/internal/users/core"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// upgradeWindow is how long after a wash was recorded the scanner can still upgrade it.
+const upgradeWindow = 30 * time.Minute
+
+func loadPackageCatalog(db sqlx.Queryer) ([]core.WashPackage, error) {
+	out := []core.WashPackage{}
+	q := `SELECT id, name, description, price_cents, member_upcharge_cents, sort_order, active FROM wash_packages ORDER BY sort_order, id`
+	if err := sqlx.Select(db, &out, q); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// loadPlanPackages returns the package IDs each plan includes.
+func loadPlanPackages(db sqlx.Queryer) (map[string][]string, error) {
+	var rows []struct {
+		PlanID    string `db:"plan_id"`
+		PackageID string `db:"package_id"`
+	}
+	q := `SELECT pp.plan_id, pp.package_id FROM plan_packages pp JOIN wash_packages p ON p.id = pp.package_id ORDER BY pp.plan_id, p.sort_order`
+	if err := sqlx.Select(db, &rows, q); err != nil {
+		return nil, err
+	}
+	out := map[string][]string{}
+	for _, r := range rows {
+		out[r.PlanID] = append(out[r.PlanID], r.PackageID)
+	}
+	return out, nil
+}
+
+// packageOffers prices the catalog for a member on planID ("" for none).
+func packageOffers(db *sqlx.DB, planID string) ([]core.PackageOffer, error) {
+	catalog, err := loadPackageCatalog(db)
+	if err != nil {
+		return nil, err
+	}
+	var included []string
+	if planID != "" {
+		q := db.Rebind(`SELECT package_id FROM plan_packages WHERE plan_id = ?`)
+		if err := db.Select(&included, q, planID); err != nil {
+			return nil, err
+		}
+	}
+	return core.PackageOffers(catalog, included), nil
+}
+
+// ListPackages gives the scanner the catalog to offer at the lane.
+func (s *ScanAPIService) ListPackages(c echo.Context) error {
+	catalog, err := loadPackageCatalog(s.db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"packages": catalog})
+}
+
+type UpgradeRequest struct {
+	EventID   string `json:"eventId"`
+	PackageID string `json:"packageId"`
+}
+
+type UpgradeResponse struct {
+	EventID       string `json:"eventId"`
+	PackageID     string `json:"packageId"`
+	PackageName   string `json:"packageName"`
+	UpchargeCents int    `json:"upchargeCents"`
+}
+
+// Upgrade moves a just-recorded wash to another package, priced for the
+// member's plan, when they take the upsell at the lane.
+func (s *ScanAPIService) Upgrade(c echo.Context) error {
+	var req UpgradeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+	}
+	req.EventID = strings.TrimSpace(req.EventID)
+	req.PackageID = strings.TrimSpace(req.PackageID)
+	if req.EventID == "" || req.PackageID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "eventId and packageId are required"})
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer tx.Rollback()
+
+	var ev struct {
+		UserID     sql.NullInt64  `db:"user_id"`
+		LocationID sql.NullString `db:"location_id"`
+		ScannedAt  time.Time      `db:"scanned_at"`
+		Result     string         `db:"result"`
+		CarID      sql.NullString `db:"car_id"`
+		PackageID  sql.NullString `db:"package_id"`
+	}
+	q := tx.Rebind(`SELECT user_id, location_id, scanned_at, result, car_id, package_id FROM wash_events WHERE id = ? FOR UPDATE`)
+	if err := tx.Get(&ev, q, req.EventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "wash event not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if d, ok := deviceFrom(c); ok {
+		if d.LocationID != ev.LocationID.String {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "wash was recorded at another location"})
+		}
+	} else if p, _ := principalFrom(c); !p.CanAccessLocation(ev.LocationID.String) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not assigned to this location"})
+	}
+	if ev.Result != "allowed" && ev.Result != "allowed_override" && ev.Result != "comp" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "only recorded washes can be upgraded"})
+	}
+	if time.Since(ev.ScannedAt) > upgradeWindow {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "wash is too old to upgrade"})
+	}
+
+	// Comp washes are free whatever the package; members pay their plan's price.
+	planID := ""
+	if ev.Result != "comp" && ev.UserID.Valid {
+		if sub, err := coveringSubscription(s.db, int(ev.UserID.Int64), ev.CarID.String); err == nil {
+			planID = sub.PlanID
+		}
+	}
+	offers, err := packageOffers(s.db, planID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	pkg, reason := core.ChoosePackage(offers, req.PackageID)
+	if reason != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": reason})
+	}
+	upcharge := pkg.UpchargeCents
+	if ev.Result == "comp" {
+		upcharge = 0
+	}
+
+	q = tx.Rebind(`UPDATE wash_events SET package_id = ?, upcharge_cents = ? WHERE id = ?`)
+	if _, err := tx.Exec(q, pkg.PackageID, upcharge, req.EventID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	actor, _ := principalFrom(c)
+	writeAudit(s.db, actor.UserID, "scan.upgrade", "wash_event", req.EventID, map[string]any{
+		"from":          ev.PackageID.String,
+		"to":            pkg.PackageID,
+		"upchargeCents": upcharge,
+		"deviceId":      scanSourceFrom(c, "").DeviceID,
+	})
+	return c.JSON(http.StatusOK, UpgradeResponse{
+		EventID:       req.EventID,
+		PackageID:     pkg.PackageID,
+		PackageName:   pkg.Name,
+		UpchargeCents: upcharge,
+	})
+}
+
+type packageReq struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	Description         string `json:"description"`
+	PriceCents          int    `json:"priceCents"`
+	MemberUpchargeCents int    `json:"memberUpchargeCents"`
+	SortOrder           int    `json:"sortOrder"`
+	// Active defaults to true on create; on update, left out keeps the current value.
+	Active *bool `json:"active"`
+}
+
+func (r *packageReq) validate() string {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Description = strings.TrimSpace(r.Description)
+	if r.Name == "" {
+		return "name is required"
+	}
+	if r.PriceCents < 0 || r.MemberUpchargeCents < 0 {
+		return "prices must be >= 0"
+	}
+	return ""
+}
+
+func (a *AdminAPIService) ListPackages(c echo.Context) error {
+	catalog, err := loadPackageCatalog(a.db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"packages": catalog})
+}
+
+func (a *AdminAPIService) CreatePackage(c echo.Context) error {
+	var req packageReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	req.ID = strings.TrimSpace(req.ID)
+	if req.ID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "id is required"})
+	}
+	if msg := req.validate(); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+	active := req.Active == nil || *req.Active
+
+	q := a.db.Rebind(`
+		INSERT INTO wash_packages (id, name, description, price_cents, member_upcharge_cents, sort_order, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if _, err := a.db.Exec(q, req.ID, req.Name, req.Description, req.PriceCents, req.MemberUpchargeCents, req.SortOrder, active); err != nil {
+		if isUniqueViolation(err) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "package id already exists"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	a.audit(c, "package.create", "wash_package", req.ID, map[string]any{
+		"name": req.Name, "priceCents": req.PriceCents, "memberUpchargeCents": req.MemberUpchargeCents, "active": active,
+	})
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+// UpdatePackage edits a package. Retire packages with active=false rather
+// than deleting them; past wash events point at them.
+func (a *AdminAPIService) UpdatePackage(c echo.Context) error {
+	id := strings.TrimSpace(c.Param("id"))
+	var req packageReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	if msg := req.validate(); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	q := a.db.Rebind(`
+		UPDATE wash_packages
+		SET name = ?, description = ?, price_cents = ?, member_upcharge_cents = ?, sort_order = ?,
+		    active = COALESCE(?, active)
+		WHERE id = ?
+	`)
+	res, err := a.db.Exec(q, req.Name, req.Description, req.PriceCents, req.MemberUpchargeCents, req.SortOrder, req.Active, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "package not found"})
+	}
+	detail := map[string]any{"name": req.Name, "priceCents": req.PriceCents, "memberUpchargeCents": req.MemberUpchargeCents}
+	if req.Active != nil {
+		detail["active"] = *req.Active
+	}
+	a.audit(c, "package.update", "wash_package", id, detail)
+	return c.JSON(http.StatusOK, map[string]any{"ok": true})
+}
+
+func (a *AdminAPIService) packagesExist(packageIDs []string) error {
+	for _, id := range packageIDs {
+		var exists int
+		if err := a.db.Get(&exists, a.db.Rebind(`SELECT COUNT(1) FROM wash_packages WHERE id = ?`), id); err != nil || exists == 0 {
+			return errors.New("unknown wash package: " + id)
+		}
+	}
+	return nil
+}
+
+// setPlanPackages replaces the packages a plan includes.
+func (a *AdminAPIService) setPlanPackages(planID string, packageIDs []string) error {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(tx.Rebind(`DELETE FROM plan_packages WHERE plan_id = ?`), planID); err != nil {
+		return err
+	}
+	for _, id := range packageIDs {
+		if _, err := tx.Exec(tx.Rebind(`INSERT INTO plan_packages (plan_id, package_id) VALUES (?, ?) ON CONFLICT DO NOTHING`), planID, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PlanEntitlements are the wash rules a plan grants. Zero limits and empty
+// lists mean unlimited / anywhere. Which packages a plan includes is kept in
+// plan_packages, not here.
+type PlanEntitlements struct {
+	WashesPerDay    int      `json:"washesPerDay,omitempty"`
+	WashesPerWeek   int      `json:"washesPerWeek,omitempty"`
+	WashesPerPeriod int      `json:"washesPerPeriod,omitempty"`
+	LocationIDs     []string `json:"locationIds,omitempty"`
+	// MinIntervalMinutes is the shortest gap allowed between two washes.
+	MinIntervalMinutes int `json:"minIntervalMinutes,omitempty"`
+}
+
+func (e PlanEntitlements) Validate() error {
+	if e.WashesPerDay < 0 || e.WashesPerWeek < 0 || e.WashesPerPeriod < 0 || e.MinIntervalMinutes < 0 {
+		return errors.New("entitlement limits must be >= 0")
+	}
+	return nil
+}
+
+// WashUsage is a member's allowed washes counted for the current day, week
+// (Monday start) and billing period.
+type WashUsage struct {
+	Today      int
+	ThisWeek   int
+	ThisPeriod int
+	LastWashAt *time.Time
+}
+
+// Check returns why a wash is denied, or "" if the plan allows it.
+func (e PlanEntitlements) Check(u WashUsage, locationID string, now time.Time) string {
+	if len(e.LocationIDs) > 0 && !slices.Contains(e.LocationIDs, locationID) {
+		return "Plan not valid at this location"
+	}
+	if e.WashesPerDay > 0 && u.Today >= e.WashesPerDay {
+		return fmt.Sprintf("Daily limit reached, %d/%d", u.Today, e.WashesPerDay)
+	}
+	if e.WashesPerWeek > 0 && u.ThisWeek >= e.WashesPerWeek {
+		return fmt.Sprintf("Weekly limit reached, %d/%d", u.ThisWeek, e.WashesPerWeek)
+	}
+	if e.WashesPerPeriod > 0 && u.ThisPeriod >= e.WashesPerPeriod {
+		return fmt.Sprintf("Monthly limit reached, %d/%d", u.ThisPeriod, e.WashesPerPeriod)
+	}
+	if e.MinIntervalMinutes > 0 && u.LastWashAt != nil {
+		next := u.LastWashAt.Add(time.Duration(e.MinIntervalMinutes) * time.Minute)
+		if wait := next.Sub(now); wait > 0 {
+			return "Too soon since last wash, try again in " + waitText(wait)
+		}
+	}
+	return ""
+}
+
+func waitText(d time.Duration) string {
+	mins := int(d.Round(time.Minute).Minutes())
+	if mins < 1 {
+		mins = 1
+	}
+	if mins < 60 {
+		return fmt.Sprintf("%dm", mins)
+	}
+	if mins%60 == 0 {
+		return fmt.Sprintf("%dh", mins/60)
+	}
+	return fmt.Sprintf("%dh %dm", mins/60, mins%60)
+}
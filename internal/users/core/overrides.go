@@ -0,0 +1,33 @@
+package core
+
+type ReasonCode struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// OverrideReasons are the accepted reasons for letting a denied scan through.
+var OverrideReasons = []ReasonCode{
+	{"expired_code", "Member's QR code expired or was already used"},
+	{"device_issue", "Member's phone is dead or the code won't display"},
+	{"billing_pending", "Payment is being fixed with the member"},
+	{"system_error", "Scanner or server problem"},
+	{"manager_approval", "Approved by the site manager"},
+	{"other", "Other (explain in the note)"},
+}
+
+// CompReasons are the accepted reasons for a free wash given to a non-member.
+var CompReasons = []ReasonCode{
+	{"service_recovery", "Making up for a bad wash or a complaint"},
+	{"promotion", "Promotion or free trial"},
+	{"goodwill", "Goodwill gesture"},
+	{"other", "Other (explain in the note)"},
+}
+
+func IsReasonCode(catalog []ReasonCode, code string) bool {
+	for _, r := range catalog {
+		if r.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package core
+
+// WashPackage is a wash the site sells. PriceCents is the walk-in price;
+// MemberUpchargeCents is what a member pays when their plan doesn't include it.
+type WashPackage struct {
+	ID                  string `json:"id" db:"id"`
+	Name                string `json:"name" db:"name"`
+	Description         string `json:"description" db:"description"`
+	PriceCents          int    `json:"priceCents" db:"price_cents"`
+	MemberUpchargeCents int    `json:"memberUpchargeCents" db:"member_upcharge_cents"`
+	SortOrder           int    `json:"sortOrder" db:"sort_order"`
+	Active              bool   `json:"active" db:"active"`
+}
+
+// PackageOffer is a package as priced for a member on a given plan.
+type PackageOffer struct {
+	PackageID     string `json:"packageId"`
+	Name          string `json:"name"`
+	Included      bool   `json:"included"`
+	UpchargeCents int    `json:"upchargeCents"`
+}
+
+// PackageOffers prices the active catalog (in sort order) for a plan that
+// includes the given package IDs.
+func PackageOffers(catalog []WashPackage, included []string) []PackageOffer {
+	inc := make(map[string]bool, len(included))
+	for _, id := range included {
+		inc[id] = true
+	}
+	offers := []PackageOffer{}
+	for _, p := range catalog {
+		if !p.Active {
+			continue
+		}
+		o := PackageOffer{PackageID: p.ID, Name: p.Name, Included: inc[p.ID]}
+		if !o.Included {
+			o.UpchargeCents = p.MemberUpchargeCents
+		}
+		offers = append(offers, o)
+	}
+	return offers
+}
+
+// ChoosePackage picks the requested offer, or the first included one when
+// nothing was requested. It returns nil with no reason when the plan
+// includes no package and none was asked for.
+func ChoosePackage(offers []PackageOffer, requested string) (*PackageOffer, string) {
+	for i := range offers {
+		if requested == "" && offers[i].Included || requested != "" && offers[i].PackageID == requested {
+			return &offers[i], ""
+		}
+	}
+	if requested != "" {
+		return nil, "Unknown wash package"
+	}
+	return nil, ""
+}
@@ -0,0 +1,77 @@
+package core
+
+// PaymentCustomer is the member a provider-side customer is created for.
+type PaymentCustomer struct {
+	UserID int
+	Email  string
+	Name   string
+}
+
+// PaymentMethod is a card saved with the provider. Only display details are kept.
+type PaymentMethod struct {
+	ID       string `json:"id" db:"id"`
+	Brand    string `json:"brand" db:"brand"`
+	Last4    string `json:"last4" db:"last4"`
+	ExpMonth int    `json:"expMonth" db:"exp_month"`
+	ExpYear  int    `json:"expYear" db:"exp_year"`
+}
+
+type ChargeRequest struct {
+	UserID          int
+	Email           string
+	CustomerID      string
+	PaymentMethodID string
+	AmountCents     int
+	Currency        string
+	Description     string
+	// IdempotencyKey identifies the charge so a retried request is only taken once.
+	IdempotencyKey string
+}
+
+// Charge statuses. A pending charge is settled later by a webhook.
+const (
+	ChargeSucceeded = "succeeded"
+	ChargePending   = "pending"
+	ChargeFailed    = "failed"
+	ChargeRefunded  = "refunded"
+)
+
+type Charge struct {
+	ID          string
+	AmountCents int
+	Status      string
+}
+
+type Refund struct {
+	ID          string
+	ChargeID    string
+	AmountCents int
+}
+
+// Webhook event types the app acts on. Providers' own names are mapped to these.
+const (
+	PaymentEventSucceeded = "charge.succeeded"
+	PaymentEventFailed    = "charge.failed"
+	PaymentEventRefunded  = "charge.refunded"
+)
+
+// PaymentEvent is a verified webhook delivery. AmountCents is the total
+// refunded so far for PaymentEventRefunded.
+type PaymentEvent struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	ChargeID      string `json:"chargeId"`
+	AmountCents   int    `json:"amountCents"`
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+// DeclineError is a charge the provider refused, as opposed to one that
+// couldn't be attempted. Message is safe to show the member.
+type DeclineError struct {
+	Code    string
+	Message string
+}
+
+func (e *DeclineError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,64 @@
+package core
+
+type Permission string
+
+const (
+	PermScanPerform        Permission = "scan.perform"
+	PermScanOverride       Permission = "scan.override"
+	PermMembersRead        Permission = "members.read"
+	PermMembersDelete      Permission = "members.delete"
+	PermMembersUnlock      Permission = "members.unlock"
+	PermMembersImpersonate Permission = "members.impersonate"
+	PermPlansRead          Permission = "plans.read"
+	PermPlansWrite         Permission = "plans.write"
+	PermLocationsRead      Permission = "locations.read"
+	PermLocationsWrite     Permission = "locations.write"
+	PermLocationsAll       Permission = "locations.all"
+	PermDevicesManage      Permission = "devices.manage"
+	PermStatsRead          Permission = "stats.read"
+	PermAuditRead          Permission = "audit.read"
+	PermRolesManage        Permission = "roles.manage"
+	PermPaymentsManage     Permission = "payments.manage"
+)
+
+type PermissionInfo struct {
+	Key         Permission `json:"key"`
+	Description string     `json:"description"`
+}
+
+// Permissions is the full catalog roles can be granted from.
+var Permissions = []PermissionInfo{
+	{PermScanPerform, "Scan member codes at a location"},
+	{PermScanOverride, "Let denied scans through and give comp washes"},
+	{PermMembersRead, "View members and their wash history"},
+	{PermMembersDelete, "Delete member accounts"},
+	{PermMembersUnlock, "Unlock accounts locked after failed sign-ins"},
+	{PermMembersImpersonate, "Sign in as a member for troubleshooting"},
+	{PermPlansRead, "View plans"},
+	{PermPlansWrite, "Create, edit, delete and reassign plans"},
+	{PermLocationsRead, "View locations"},
+	{PermLocationsWrite, "Create, edit and delete locations"},
+	{PermLocationsAll, "Access every location (otherwise only assigned ones)"},
+	{PermDevicesManage, "Register, edit and revoke scanner devices"},
+	{PermStatsRead, "View dashboard stats and charts"},
+	{PermAuditRead, "View the admin audit log"},
+	{PermRolesManage, "Create roles and change user roles"},
+	{PermPaymentsManage, "View payments and issue refunds"},
+}
+
+func IsPermission(p string) bool {
+	for _, info := range Permissions {
+		if string(info.Key) == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Built-in role IDs. Custom roles can be added at runtime.
+const (
+	RoleAdmin     = "admin"
+	RoleAttendant = "attendant"
+	RoleManager   = "manager"
+	RoleMember    = "member"
+)
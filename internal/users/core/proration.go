@@ -0,0 +1,51 @@
+package core
+
+import "time"
+
+// Plan change timings.
+const (
+	ChangeImmediate = "immediate"
+	ChangePeriodEnd = "period_end"
+)
+
+// Proration is the cost of switching plans partway through a billing period.
+// A negative AmountDueCents is credited against the next renewal.
+type Proration struct {
+	PeriodStart    string `json:"periodStart"`
+	PeriodEnd      string `json:"periodEnd"`
+	DaysLeft       int    `json:"daysLeft"`
+	DaysInPeriod   int    `json:"daysInPeriod"`
+	CreditCents    int    `json:"creditCents"`
+	ChargeCents    int    `json:"chargeCents"`
+	AmountDueCents int    `json:"amountDueCents"`
+}
+
+// Prorate credits the unused days of the old price and charges the new
+// price for the same days. Days are whole UTC days; today counts as unused.
+func Prorate(oldPriceCents, newPriceCents int, periodStart, periodEnd, now time.Time) Proration {
+	day := func(t time.Time) time.Time {
+		t = t.UTC()
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	start, end, today := day(periodStart), day(periodEnd), day(now)
+	total := int(end.Sub(start).Hours() / 24)
+	if total < 1 {
+		total = 1
+	}
+	left := int(end.Sub(today).Hours() / 24)
+	left = max(0, min(left, total))
+
+	share := func(cents int) int {
+		return (cents*left + total/2) / total
+	}
+	p := Proration{
+		PeriodStart:  start.Format("2006-01-02"),
+		PeriodEnd:    end.Format("2006-01-02"),
+		DaysLeft:     left,
+		DaysInPeriod: total,
+		CreditCents:  share(oldPriceCents),
+		ChargeCents:  share(newPriceCents),
+	}
+	p.AmountDueCents = p.ChargeCents - p.CreditCents
+	return p
+}
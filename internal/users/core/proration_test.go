@@ -0,0 +1,82 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProrate(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name               string
+		oldCents, newCents int
+		start, end, now    string
+		want               Proration
+	}{
+		{
+			name:     "upgrade on the first day",
+			oldCents: 3000, newCents: 6000,
+			start: "2026-03-01", end: "2026-03-31", now: "2026-03-01",
+			want: Proration{DaysLeft: 30, DaysInPeriod: 30, CreditCents: 3000, ChargeCents: 6000, AmountDueCents: 3000},
+		},
+		{
+			name:     "upgrade on the last day",
+			oldCents: 3000, newCents: 6000,
+			start: "2026-03-01", end: "2026-03-31", now: "2026-03-30",
+			want: Proration{DaysLeft: 1, DaysInPeriod: 30, CreditCents: 100, ChargeCents: 200, AmountDueCents: 100},
+		},
+		{
+			name:     "on the renewal date nothing is left",
+			oldCents: 3000, newCents: 6000,
+			start: "2026-03-01", end: "2026-03-31", now: "2026-03-31",
+			want: Proration{DaysLeft: 0, DaysInPeriod: 30, CreditCents: 0, ChargeCents: 0, AmountDueCents: 0},
+		},
+		{
+			name:     "downgrade credits the difference",
+			oldCents: 6000, newCents: 3000,
+			start: "2026-03-01", end: "2026-03-31", now: "2026-03-16",
+			want: Proration{DaysLeft: 15, DaysInPeriod: 30, CreditCents: 3000, ChargeCents: 1500, AmountDueCents: -1500},
+		},
+		{
+			name:     "from a free plan",
+			oldCents: 0, newCents: 3000,
+			start: "2026-03-01", end: "2026-03-31", now: "2026-03-16",
+			want: Proration{DaysLeft: 15, DaysInPeriod: 30, CreditCents: 0, ChargeCents: 1500, AmountDueCents: 1500},
+		},
+		{
+			name:     "to a free plan",
+			oldCents: 3000, newCents: 0,
+			start: "2026-03-01", end: "2026-03-31", now: "2026-03-16",
+			want: Proration{DaysLeft: 15, DaysInPeriod: 30, CreditCents: 1500, ChargeCents: 0, AmountDueCents: -1500},
+		},
+		{
+			name:     "shares round to the nearest cent",
+			oldCents: 1000, newCents: 2000,
+			start: "2026-01-01", end: "2026-02-01", now: "2026-01-22",
+			want: Proration{DaysLeft: 10, DaysInPeriod: 31, CreditCents: 323, ChargeCents: 645, AmountDueCents: 322},
+		},
+		{
+			name:     "an empty period counts as one day",
+			oldCents: 3000, newCents: 6000,
+			start: "2026-03-01", end: "2026-03-01", now: "2026-02-28",
+			want: Proration{DaysLeft: 1, DaysInPeriod: 1, CreditCents: 3000, ChargeCents: 6000, AmountDueCents: 3000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Late in the day UTC still prorates by the calendar day.
+			now := day(tt.now).Add(23 * time.Hour)
+			got := Prorate(tt.oldCents, tt.newCents, day(tt.start), day(tt.end), now)
+			tt.want.PeriodStart, tt.want.PeriodEnd = tt.start, tt.end
+			if got != tt.want {
+				t.Errorf("Prorate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+// Subscription statuses. Only active subscriptions let a car through at the
+// scanner; past_due ones are being retried by the renewal engine and paused
+// ones resume on their own. An active subscription may also be set to
+// cancel at the end of its period.
+const (
+	SubscriptionActive   = "active"
+	SubscriptionPastDue  = "past_due"
+	SubscriptionPaused   = "paused"
+	SubscriptionCanceled = "canceled"
+)
+
+// MaxPauseWeeks is the longest vacation hold a member can take at once.
+const MaxPauseWeeks = 12
+
+// CancelReasonPaymentFailed is recorded when the renewal engine cancels
+// after the grace period; members can't pick it.
+const CancelReasonPaymentFailed = "payment_failed"
+
+// CancelReasons are what members can give when canceling, for churn reporting.
+var CancelReasons = []ReasonCode{
+	{"too_expensive", "It costs too much"},
+	{"not_using", "I don't wash often enough"},
+	{"moving", "I'm moving away"},
+	{"vehicle_sold", "I sold or replaced the car"},
+	{"service_quality", "Unhappy with the wash quality or service"},
+	{"switching", "Switching to another car wash"},
+	{"other", "Other (explain in the note)"},
+}
+
+// Why a subscription period started or ended. A plan change ends one
+// period and starts the next with the same reason.
+const (
+	PeriodSubscribed      = "subscribed"
+	PeriodResubscribed    = "resubscribed"
+	PeriodUpgrade         = "upgrade"
+	PeriodDowngrade       = "downgrade"
+	PeriodPlanChange      = "plan_change"
+	PeriodScheduledChange = "scheduled_change"
+	PeriodReassigned      = "reassigned"
+	PeriodPaused          = "paused"
+	PeriodResumed         = "resumed"
+	PeriodCanceled        = "canceled"
+)
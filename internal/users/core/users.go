@@ -13,4 +13,9 @@ type User struct {
 	FirstName string    `db:"first_name" json:"firstName"`
 	LastName  string    `db:"last_name" json:"lastName"`
 	AvatarURL string    `db:"avatar_url" json:"avatarUrl"`
+	Role      string    `db:"role" json:"role"`
+
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"emailVerifiedAt,omitempty"`
+	// PaymentCustomerID is the member's customer at the payment provider.
+	PaymentCustomerID *string `db:"payment_customer_id" json:"-"`
 }
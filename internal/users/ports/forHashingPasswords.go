@@ -0,0 +1,8 @@
+package ports
+
+type HashingPasswords interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash, and whether the stored
+	// hash should be replaced (legacy algorithm or outdated cost).
+	Verify(hash, password string) (ok bool, needsRehash bool)
+}
@@ -1,8 +1,6 @@
 package ports
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
 
 	"github.com/edlingao/hexago/internal/users/core"
@@ -13,17 +11,19 @@ type UserServiceMethods interface {
 	SignIn(username string, password string) (core.User, error)
 	Get(id string) (core.User, error)
 	GetByUsername(username string) (core.User, error)
-	EncryptPassword(password string) string
+	HashPassword(password string) (string, error)
 	ValidatePassword(hash string, password string) bool
 }
 
 type UserService struct {
 	DBService StoringUsers
+	Hasher    HashingPasswords
 }
 
-func NewUserService(db StoringUsers) UserService {
+func NewUserService(db StoringUsers, hasher HashingPasswords) UserService {
 	return UserService{
 		DBService: db,
+		Hasher:    hasher,
 	}
 }
 
@@ -34,10 +34,20 @@ func (us UserService) SignIn(username string, password string) (core.User, error
 		return core.User{}, err
 	}
 
-	if !us.ValidatePassword(user.Password, password) {
+	ok, needsRehash := us.Hasher.Verify(user.Password, password)
+	if !ok {
 		return core.User{}, errors.New("Invalid password")
 	}
 
+	// Transparently upgrade legacy (SHA-256 / plaintext) hashes on sign-in.
+	if needsRehash {
+		if hash, err := us.Hasher.Hash(password); err == nil {
+			if err := us.DBService.UpdatePassword(user.ID, hash); err == nil {
+				user.Password = hash
+			}
+		}
+	}
+
 	return user, nil
 }
 
@@ -46,13 +56,17 @@ func (us UserService) Register(username, password string) error {
 		return errors.New("Username and password are required")
 	}
 
+	hash, err := us.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
 	user := core.User{
 		Username: username,
-		Password: us.EncryptPassword(password),
+		Password: hash,
 	}
 
-	err := us.DBService.Insert(user)
-	return err
+	return us.DBService.Insert(user)
 }
 
 func (us UserService) Get(id string) (core.User, error) {
@@ -67,13 +81,11 @@ func (us UserService) GetByUsername(username string) (core.User, error) {
 	return us.DBService.GetByField("username", username, "users")
 }
 
-func (us UserService) EncryptPassword(password string) string {
-	plain_password := []byte(password)
-	hash := sha256.Sum256(plain_password)
-
-	return hex.EncodeToString(hash[:])
+func (us UserService) HashPassword(password string) (string, error) {
+	return us.Hasher.Hash(password)
 }
 
 func (us UserService) ValidatePassword(hash string, password string) bool {
-	return us.EncryptPassword(password) == hash
+	ok, _ := us.Hasher.Verify(hash, password)
+	return ok
 }
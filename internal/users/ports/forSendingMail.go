@@ -0,0 +1,7 @@
+package ports
+
+import "github.com/edlingao/hexago/internal/users/core"
+
+type SendingMail interface {
+	Send(msg core.MailMessage) error
+}
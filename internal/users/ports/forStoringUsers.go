@@ -10,6 +10,7 @@ type StoringUsers interface {
 	DeleteByField(field, value, table string) error
 	GetAll(table string) []core.User
 	Delete(id, table string) error
+	UpdatePassword(id, hash string) error
 }
 
 type StoringSessions[T any] interface {
@@ -0,0 +1,17 @@
+package ports
+
+import "github.com/edlingao/hexago/internal/users/core"
+
+type TakingPayments interface {
+	CreateCustomer(customer core.PaymentCustomer) (customerID string, err error)
+	// AttachPaymentMethod saves the card behind a client-side token to the customer.
+	AttachPaymentMethod(customerID, token string) (core.PaymentMethod, error)
+	// Charge takes req.AmountCents from the member. A declined charge returns
+	// a *core.DeclineError; retrying with the same IdempotencyKey never
+	// charges twice.
+	Charge(req core.ChargeRequest) (core.Charge, error)
+	// Refund gives back amountCents of a charge.
+	Refund(chargeID string, amountCents int, idempotencyKey string) (core.Refund, error)
+	// ParseWebhook verifies a provider callback's signature and decodes it.
+	ParseWebhook(payload []byte, signature string) (core.PaymentEvent, error)
+}
@@ -7,8 +7,23 @@ type MeResponse = {
   avatarUrl: string;
 };
 
+type SubscriptionPeriod = {
+  id: number;
+  subscriptionId: string;
+  carName?: string;
+  planId: string;
+  planName: string;
+  priceCents: number;
+  startedOn: string;
+  endedOn?: string;
+  startReason: string;
+  endReason?: string;
+  cancelReason?: string;
+};
+
 type SubResponse = {
   active: boolean;
+  timeline?: SubscriptionPeriod[];
   subscription: null | {
     planId: string;
     planName: string;
@@ -28,6 +43,7 @@ export function accountStore() {
 
     user: null as any,
     subscription: null as any,
+    timeline: [] as SubscriptionPeriod[],
 
     avatarPreview: '' as string,
     firstName: '',
@@ -79,6 +95,7 @@ export function accountStore() {
         } else {
           this.subscription = null;
         }
+        this.timeline = subJson?.timeline || [];
       } catch (e: any) {
         this.error = e?.message ?? 'Failed to load account';
       } finally {
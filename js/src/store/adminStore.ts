@@ -36,6 +36,21 @@ type AdminWashEvent = {
   rawQr: string;
 };
 
+type SubscriptionPeriod = {
+  id: number;
+  subscriptionId: string;
+  carId?: string;
+  carName?: string;
+  planId: string;
+  planName: string;
+  priceCents: number;
+  startedOn: string;
+  endedOn?: string;
+  startReason: string;
+  endReason?: string;
+  cancelReason?: string;
+};
+
 export function adminStore() {
   return {
     // portal UI expects these
@@ -86,6 +101,7 @@ export function adminStore() {
     memberDetailError: null as string | null,
     memberDetail: null as AdminMember | null,
     memberDetailEvents: [] as AdminWashEvent[],
+    memberDetailTimeline: [] as SubscriptionPeriod[],
 
     // Plans
     plans: [] as AdminPlan[],
@@ -383,6 +399,7 @@ export function adminStore() {
       this.memberDetailError = null;
       this.memberDetail = null;
       this.memberDetailEvents = [];
+      this.memberDetailTimeline = [];
 
       try {
         const res = await fetch(`/api/v1/admin/members/${id}`, { credentials: 'include' });
@@ -391,6 +408,7 @@ export function adminStore() {
 
         this.memberDetail = (j.member || null) as AdminMember | null;
         this.memberDetailEvents = (j.washEvents || []) as AdminWashEvent[];
+        this.memberDetailTimeline = (j.subscriptionTimeline || []) as SubscriptionPeriod[];
       } catch (e: any) {
         this.memberDetailError = e?.message ?? 'Failed to load member';
         this.toast(this.memberDetailError, 'error');
@@ -405,6 +423,7 @@ export function adminStore() {
       this.memberDetailError = null;
       this.memberDetail = null;
       this.memberDetailEvents = [];
+      this.memberDetailTimeline = [];
     },
 
 
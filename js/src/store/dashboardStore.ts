@@ -36,6 +36,12 @@ type SubResponse = {
   };
 };
 
+type QRResponse = {
+  qr: string;
+  expiresAt: string;
+  ttlSeconds: number;
+};
+
 type HistoryItem = {
   id: string;
   userId: number;
@@ -52,6 +58,7 @@ export function dashboardStore() {
   return {
     accessCode: '' as string,
     accessQrUrl: '' as string,
+    accessRefreshId: null as any,
 
     user: null as User | null,
     subscription: null as any, // keep template getters working
@@ -164,7 +171,7 @@ export function dashboardStore() {
         });
 
         // 4) QR for dashboard card
-        this.generateAccessCode();
+        await this.generateAccessCode();
       } catch (e: any) {
         this.error = e?.message ?? 'Failed to load dashboard';
       } finally {
@@ -172,15 +179,30 @@ export function dashboardStore() {
       }
     },
 
-    generateAccessCode() {
-      const uid = parseInt(String(this.user?.id || ''), 10);
-      if (!uid) {
+    // generateAccessCode loads the signed membership QR and fetches a new
+    // one shortly before it expires.
+    async generateAccessCode() {
+      if (this.accessRefreshId) clearTimeout(this.accessRefreshId);
+      this.accessRefreshId = null;
+
+      const res = await fetch('/api/v1/me/qr', { headers: authHeaders(), credentials: 'include' });
+      if (!res.ok) {
         this.accessCode = '';
         this.accessQrUrl = '';
         return;
       }
-      this.accessCode = `CARWASH-${uid}-${Date.now()}`;
+      const qr = (await res.json()) as QRResponse;
+      this.accessCode = qr.qr;
       this.accessQrUrl = `https://api.qrserver.com/v1/create-qr-code/?size=200x200&data=${encodeURIComponent(this.accessCode)}`;
+
+      const left = qr.expiresAt ? Date.parse(qr.expiresAt) - Date.now() : qr.ttlSeconds * 1000;
+      const wait = Math.max(5000, (Number.isFinite(left) ? left : qr.ttlSeconds * 1000) - 15000);
+      this.accessRefreshId = setTimeout(() => this.generateAccessCode(), wait);
+    },
+
+    destroy() {
+      if (this.accessRefreshId) clearTimeout(this.accessRefreshId);
+      this.accessRefreshId = null;
     },
 
     manageSubscription() {
@@ -1,3 +1,12 @@
+type QRResponse = {
+  qr: string;
+  expiresAt: string;
+  ttlSeconds: number;
+};
+
+// Fetch a fresh code this many seconds before the current one expires.
+const REFRESH_BEFORE_EXPIRY = 15;
+
 export function qrCodeStore() {
   return {
     user: null as any,
@@ -28,13 +37,16 @@ export function qrCodeStore() {
       this.timer.intervalId = null;
     },
 
-    startTimer() {
+    startTimer(seconds: number) {
       this.destroy();
-      this.timer.total = 300;
+      this.timer.total = Math.max(0, Math.floor(seconds));
       this.timer.expired = false;
 
       this.timer.intervalId = setInterval(() => {
         this.timer.total -= 1;
+        if (this.timer.total === REFRESH_BEFORE_EXPIRY) {
+          this.refreshCode();
+        }
         if (this.timer.total <= 0) {
           this.timer.total = 0;
           this.timer.expired = true;
@@ -63,11 +75,16 @@ export function qrCodeStore() {
           this.planName = 'Active Plan';
         }
 
-        // IMPORTANT: Backend expects CARWASH-<numericId>-<timestamp>
-        this.code = `CARWASH-${me.id}-${Date.now()}`;
+        // The scanner only accepts the signed, expiring payload from /me/qr.
+        const qrRes = await fetch('/api/v1/me/qr', { credentials: 'include' });
+        if (!qrRes.ok) throw new Error('Failed to load QR code');
+        const qr = (await qrRes.json()) as QRResponse;
+
+        this.code = qr.qr;
         this.qrCodeUrl = `https://api.qrserver.com/v1/create-qr-code/?size=200x200&data=${encodeURIComponent(this.code)}`;
 
-        this.startTimer();
+        const left = qr.expiresAt ? (Date.parse(qr.expiresAt) - Date.now()) / 1000 : qr.ttlSeconds;
+        this.startTimer(Number.isFinite(left) ? left : qr.ttlSeconds);
       } catch (e: any) {
         this.userName = '—';
         this.planName = '—';
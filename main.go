@@ -24,6 +24,7 @@ func main() {
 		echo,
 	)
 
+	config.AddAuth()
 	config.AddCalculatorAPI()
 	config.AddCalculatorWeb()
 	config.AddUserAPI()
@@ -35,6 +36,10 @@ func main() {
 	config.AddVinAPI()
 	config.AddLocationsAPI()
 	config.AddAdminAPI()
+	config.AddPaymentsWebhook()
 	config.AddUserWeb()
+	config.AddAccountRecovery()
+	config.AddSessionJanitor()
+	config.AddBillingRenewals()
 	config.Start()
 }
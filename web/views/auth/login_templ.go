@@ -48,7 +48,7 @@ func Login(vm LoginVM) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<link href=\"https://fonts.googleapis.com/css2?family=Material+Symbols+Outlined:wght,FILL@100..700,0..1&display=swap\" rel=\"stylesheet\"><div class=\"relative flex min-h-screen w-full flex-col bg-slate-100 overflow-x-hidden\" x-data=\"authStore\"><div class=\"flex h-full grow flex-col\"><div class=\"flex flex-1 justify-center\"><div class=\"flex flex-col w-full flex-1\"><div class=\"grid grid-cols-1 md:grid-cols-2 min-h-screen\"><!-- Left Panel - Branding --><div class=\"relative hidden md:flex flex-col items-center justify-center p-10 bg-slate-100\"><div class=\"absolute inset-0 bg-center bg-no-repeat bg-cover\" style=\"background-image: url('https://images.unsplash.com/photo-1520340356584-f9917d1eea6f?w=1200');\"><div class=\"absolute inset-0 bg-blue-600/70\"></div></div><div class=\"relative z-10 flex flex-col gap-8 max-w-md text-white text-center\"><div class=\"flex items-center justify-center gap-3\"><span class=\"material-symbols-outlined text-4xl\">local_car_wash</span><p class=\"text-2xl font-bold\">Hedgestone - Carwash</p></div><div class=\"flex flex-col gap-2\"><h1 class=\"text-4xl font-black leading-tight tracking-tight\">Your Car, Perfectly Clean.</h1><h2 class=\"text-lg font-normal leading-normal opacity-90\">Unlimited Washes, Unbeatable Shine.</h2></div></div></div><!-- Right Panel - Auth Form --><div class=\"flex flex-col items-center justify-center w-full bg-slate-100 p-6 sm:p-8\"><div class=\"flex flex-col w-full max-w-sm\"><!-- Mobile Logo --><div class=\"flex items-center gap-3 mb-8 md:hidden\"><span class=\"material-symbols-outlined text-3xl text-blue-600\">local_car_wash</span><p class=\"text-xl font-bold text-slate-800\">Hedgestone - Carwash</p></div><h1 class=\"text-slate-900 tracking-tight text-3xl font-bold leading-tight pb-3 pt-6 text-left\">Welcome Back</h1><p class=\"text-slate-600 pb-6\">Enter your credentials to access your account.</p><!-- Tab Toggle --><div class=\"flex px-0 py-3\"><div class=\"flex h-10 flex-1 items-center justify-center rounded-lg bg-slate-200 p-1\"><label class=\"flex cursor-pointer h-full grow items-center justify-center overflow-hidden rounded-lg px-2 text-sm font-medium leading-normal transition-colors\" :class=\"activeTab === 'login' ? 'bg-white shadow-sm text-slate-900' : 'text-slate-500'\"><span class=\"truncate\">Login</span> <input class=\"invisible w-0\" type=\"radio\" name=\"auth-toggle\" value=\"login\" x-model=\"activeTab\" @change=\"switchTab('login')\"></label> <label class=\"flex cursor-pointer h-full grow items-center justify-center overflow-hidden rounded-lg px-2 text-sm font-medium leading-normal transition-colors\" :class=\"activeTab === 'register' ? 'bg-white shadow-sm text-slate-900' : 'text-slate-500'\"><span class=\"truncate\">Register</span> <input class=\"invisible w-0\" type=\"radio\" name=\"auth-toggle\" value=\"register\" x-model=\"activeTab\" @change=\"switchTab('register')\"></label></div></div><!-- Error Message --><div x-show=\"error\" x-cloak class=\"bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-lg mb-4\"><span x-text=\"error\"></span></div><!-- Form Fields --><form @submit.prevent=\"activeTab === 'login' ? login() : register()\" class=\"flex w-full flex-col gap-4 py-3\"><!-- Name Fields (Register only) --><div x-show=\"activeTab === 'register'\" x-cloak class=\"grid grid-cols-2 gap-4\"><label class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">First Name</p><input x-model=\"firstName\" class=\"form-input flex w-full rounded-lg text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 text-base font-normal\" placeholder=\"First name\" type=\"text\"></label> <label class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">Last Name</p><input x-model=\"lastName\" class=\"form-input flex w-full rounded-lg text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 text-base font-normal\" placeholder=\"Last name\" type=\"text\"></label></div><!-- Email Field --><label class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">Email Address</p><div class=\"flex w-full flex-1 items-stretch rounded-lg\"><div class=\"text-slate-500 flex border border-slate-300 bg-slate-100 items-center justify-center pl-4 rounded-l-lg border-r-0\"><span class=\"material-symbols-outlined\">mail</span></div><input x-model=\"email\" class=\"form-input flex w-full min-w-0 flex-1 rounded-lg text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 rounded-l-none text-base font-normal\" placeholder=\"Enter your email address\" type=\"email\" required></div></label><!-- Password Field --><label class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">Password</p><div class=\"flex w-full flex-1 items-stretch rounded-lg\"><div class=\"text-slate-500 flex border border-slate-300 bg-slate-100 items-center justify-center pl-4 rounded-l-lg border-r-0\"><span class=\"material-symbols-outlined\">lock</span></div><input x-model=\"password\" :type=\"showPassword ? 'text' : 'password'\" class=\"form-input flex w-full min-w-0 flex-1 text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 text-base font-normal border-x-0\" placeholder=\"Enter your password\" required><div @click=\"togglePassword()\" class=\"text-slate-500 flex border border-slate-300 bg-slate-100 items-center justify-center pr-4 rounded-r-lg border-l-0 cursor-pointer hover:text-slate-700\"><span class=\"material-symbols-outlined\" x-text=\"showPassword ? 'visibility_off' : 'visibility'\"></span></div></div></label><!-- Confirm Password (Register only) --><label x-show=\"activeTab === 'register'\" x-cloak class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">Confirm Password</p><div class=\"flex w-full flex-1 items-stretch rounded-lg\"><div class=\"text-slate-500 flex border border-slate-300 bg-slate-100 items-center justify-center pl-4 rounded-l-lg border-r-0\"><span class=\"material-symbols-outlined\">lock</span></div><input x-model=\"confirmPassword\" :type=\"showPassword ? 'text' : 'password'\" class=\"form-input flex w-full min-w-0 flex-1 rounded-lg text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 rounded-l-none text-base font-normal\" placeholder=\"Confirm your password\"></div></label><!-- Forgot Password Link --><div x-show=\"activeTab === 'login'\" class=\"flex items-center justify-end px-1 py-2\"><a class=\"text-sm font-medium text-blue-600 hover:underline\" href=\"/contact\">Forgot Password?</a></div><!-- Submit Button --><div class=\"flex flex-col gap-3 pt-4\"><button type=\"submit\" :disabled=\"loading\" class=\"flex min-w-[84px] max-w-full cursor-pointer items-center justify-center overflow-hidden rounded-lg h-12 px-5 bg-blue-600 text-white text-base font-bold leading-normal tracking-wide hover:bg-blue-700 transition-colors disabled:opacity-50 disabled:cursor-not-allowed\"><span x-show=\"loading\" class=\"mr-2\"><svg class=\"animate-spin h-5 w-5\" xmlns=\"http://www.w3.org/2000/svg\" fill=\"none\" viewBox=\"0 0 24 24\"><circle class=\"opacity-25\" cx=\"12\" cy=\"12\" r=\"10\" stroke=\"currentColor\" stroke-width=\"4\"></circle> <path class=\"opacity-75\" fill=\"currentColor\" d=\"M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z\"></path></svg></span> <span x-text=\"activeTab === 'login' ? 'Login' : 'Create Account'\"></span></button></div></form><!-- Demo Credentials --><div class=\"mt-6 p-4 bg-slate-200 rounded-lg\"><p class=\"text-sm text-slate-600 font-medium mb-2\">Demo Credentials:</p><p class=\"text-sm text-slate-500\">Email: alex@example.com</p><p class=\"text-sm text-slate-500\">Password: demo123</p></div></div></div></div></div></div></div></div><style>\n\t\t\t[x-cloak] { display: none !important; }\n\t\t</style>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<link href=\"https://fonts.googleapis.com/css2?family=Material+Symbols+Outlined:wght,FILL@100..700,0..1&display=swap\" rel=\"stylesheet\"><div class=\"relative flex min-h-screen w-full flex-col bg-slate-100 overflow-x-hidden\" x-data=\"authStore\"><div class=\"flex h-full grow flex-col\"><div class=\"flex flex-1 justify-center\"><div class=\"flex flex-col w-full flex-1\"><div class=\"grid grid-cols-1 md:grid-cols-2 min-h-screen\"><!-- Left Panel - Branding --><div class=\"relative hidden md:flex flex-col items-center justify-center p-10 bg-slate-100\"><div class=\"absolute inset-0 bg-center bg-no-repeat bg-cover\" style=\"background-image: url('https://images.unsplash.com/photo-1520340356584-f9917d1eea6f?w=1200');\"><div class=\"absolute inset-0 bg-blue-600/70\"></div></div><div class=\"relative z-10 flex flex-col gap-8 max-w-md text-white text-center\"><div class=\"flex items-center justify-center gap-3\"><span class=\"material-symbols-outlined text-4xl\">local_car_wash</span><p class=\"text-2xl font-bold\">Hedgestone - Carwash</p></div><div class=\"flex flex-col gap-2\"><h1 class=\"text-4xl font-black leading-tight tracking-tight\">Your Car, Perfectly Clean.</h1><h2 class=\"text-lg font-normal leading-normal opacity-90\">Unlimited Washes, Unbeatable Shine.</h2></div></div></div><!-- Right Panel - Auth Form --><div class=\"flex flex-col items-center justify-center w-full bg-slate-100 p-6 sm:p-8\"><div class=\"flex flex-col w-full max-w-sm\"><!-- Mobile Logo --><div class=\"flex items-center gap-3 mb-8 md:hidden\"><span class=\"material-symbols-outlined text-3xl text-blue-600\">local_car_wash</span><p class=\"text-xl font-bold text-slate-800\">Hedgestone - Carwash</p></div><h1 class=\"text-slate-900 tracking-tight text-3xl font-bold leading-tight pb-3 pt-6 text-left\">Welcome Back</h1><p class=\"text-slate-600 pb-6\">Enter your credentials to access your account.</p><!-- Tab Toggle --><div class=\"flex px-0 py-3\"><div class=\"flex h-10 flex-1 items-center justify-center rounded-lg bg-slate-200 p-1\"><label class=\"flex cursor-pointer h-full grow items-center justify-center overflow-hidden rounded-lg px-2 text-sm font-medium leading-normal transition-colors\" :class=\"activeTab === 'login' ? 'bg-white shadow-sm text-slate-900' : 'text-slate-500'\"><span class=\"truncate\">Login</span> <input class=\"invisible w-0\" type=\"radio\" name=\"auth-toggle\" value=\"login\" x-model=\"activeTab\" @change=\"switchTab('login')\"></label> <label class=\"flex cursor-pointer h-full grow items-center justify-center overflow-hidden rounded-lg px-2 text-sm font-medium leading-normal transition-colors\" :class=\"activeTab === 'register' ? 'bg-white shadow-sm text-slate-900' : 'text-slate-500'\"><span class=\"truncate\">Register</span> <input class=\"invisible w-0\" type=\"radio\" name=\"auth-toggle\" value=\"register\" x-model=\"activeTab\" @change=\"switchTab('register')\"></label></div></div><!-- Error Message --><div x-show=\"error\" x-cloak class=\"bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-lg mb-4\"><span x-text=\"error\"></span></div><!-- Form Fields --><form @submit.prevent=\"activeTab === 'login' ? login() : register()\" class=\"flex w-full flex-col gap-4 py-3\"><!-- Name Fields (Register only) --><div x-show=\"activeTab === 'register'\" x-cloak class=\"grid grid-cols-2 gap-4\"><label class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">First Name</p><input x-model=\"firstName\" class=\"form-input flex w-full rounded-lg text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 text-base font-normal\" placeholder=\"First name\" type=\"text\"></label> <label class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">Last Name</p><input x-model=\"lastName\" class=\"form-input flex w-full rounded-lg text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 text-base font-normal\" placeholder=\"Last name\" type=\"text\"></label></div><!-- Email Field --><label class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">Email Address</p><div class=\"flex w-full flex-1 items-stretch rounded-lg\"><div class=\"text-slate-500 flex border border-slate-300 bg-slate-100 items-center justify-center pl-4 rounded-l-lg border-r-0\"><span class=\"material-symbols-outlined\">mail</span></div><input x-model=\"email\" class=\"form-input flex w-full min-w-0 flex-1 rounded-lg text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 rounded-l-none text-base font-normal\" placeholder=\"Enter your email address\" type=\"email\" required></div></label><!-- Password Field --><label class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">Password</p><div class=\"flex w-full flex-1 items-stretch rounded-lg\"><div class=\"text-slate-500 flex border border-slate-300 bg-slate-100 items-center justify-center pl-4 rounded-l-lg border-r-0\"><span class=\"material-symbols-outlined\">lock</span></div><input x-model=\"password\" :type=\"showPassword ? 'text' : 'password'\" class=\"form-input flex w-full min-w-0 flex-1 text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 text-base font-normal border-x-0\" placeholder=\"Enter your password\" required><div @click=\"togglePassword()\" class=\"text-slate-500 flex border border-slate-300 bg-slate-100 items-center justify-center pr-4 rounded-r-lg border-l-0 cursor-pointer hover:text-slate-700\"><span class=\"material-symbols-outlined\" x-text=\"showPassword ? 'visibility_off' : 'visibility'\"></span></div></div></label><!-- Confirm Password (Register only) --><label x-show=\"activeTab === 'register'\" x-cloak class=\"flex flex-col flex-1\"><p class=\"text-slate-900 text-base font-medium leading-normal pb-2\">Confirm Password</p><div class=\"flex w-full flex-1 items-stretch rounded-lg\"><div class=\"text-slate-500 flex border border-slate-300 bg-slate-100 items-center justify-center pl-4 rounded-l-lg border-r-0\"><span class=\"material-symbols-outlined\">lock</span></div><input x-model=\"confirmPassword\" :type=\"showPassword ? 'text' : 'password'\" class=\"form-input flex w-full min-w-0 flex-1 rounded-lg text-slate-900 focus:outline-none focus:ring-2 focus:ring-blue-500/50 border border-slate-300 bg-slate-100 h-14 placeholder:text-slate-500 p-4 rounded-l-none text-base font-normal\" placeholder=\"Confirm your password\"></div></label><!-- Forgot Password Link --><div x-show=\"activeTab === 'login'\" class=\"flex items-center justify-end px-1 py-2\"><a class=\"text-sm font-medium text-blue-600 hover:underline\" href=\"/forgot-password\">Forgot Password?</a></div><!-- Submit Button --><div class=\"flex flex-col gap-3 pt-4\"><button type=\"submit\" :disabled=\"loading\" class=\"flex min-w-[84px] max-w-full cursor-pointer items-center justify-center overflow-hidden rounded-lg h-12 px-5 bg-blue-600 text-white text-base font-bold leading-normal tracking-wide hover:bg-blue-700 transition-colors disabled:opacity-50 disabled:cursor-not-allowed\"><span x-show=\"loading\" class=\"mr-2\"><svg class=\"animate-spin h-5 w-5\" xmlns=\"http://www.w3.org/2000/svg\" fill=\"none\" viewBox=\"0 0 24 24\"><circle class=\"opacity-25\" cx=\"12\" cy=\"12\" r=\"10\" stroke=\"currentColor\" stroke-width=\"4\"></circle> <path class=\"opacity-75\" fill=\"currentColor\" d=\"M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z\"></path></svg></span> <span x-text=\"activeTab === 'login' ? 'Login' : 'Create Account'\"></span></button></div></form><!-- Demo Credentials --><div class=\"mt-6 p-4 bg-slate-200 rounded-lg\"><p class=\"text-sm text-slate-600 font-medium mb-2\">Demo Credentials:</p><p class=\"text-sm text-slate-500\">Email: alex@example.com</p><p class=\"text-sm text-slate-500\">Password: demo123</p></div></div></div></div></div></div></div></div><style>\n\t\t\t[x-cloak] { display: none !important; }\n\t\t</style>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -29,7 +29,7 @@ func PublicNavbar() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<nav class=\"bg-white shadow-sm\"><div class=\"max-w-7xl mx-auto px-4 sm:px-6 lg:px-8\"><div class=\"flex justify-between items-center h-16\"><div class=\"flex items-center\"><a href=\"/\" class=\"flex items-center\"><svg class=\"h-8 w-8\" viewBox=\"0 0 256 256\" fill=\"#4299E1\"><path d=\"M240,112H229.2L201.42,49.5A16,16,0,0,0,186.8,40H69.2a16,16,0,0,0-14.62,9.5L26.8,112H16a8,8,0,0,0,0,16h8v80a16,16,0,0,0,16,16H64a16,16,0,0,0,16-16V192h96v16a16,16,0,0,0,16,16h24a16,16,0,0,0,16-16V128h8a8,8,0,0,0,0-16ZM69.2,56H186.8l24.89,56H44.31ZM64,208H40V192H64Zm128,0V192h24v16Zm24-32H40V128H216ZM56,152a8,8,0,0,1,8-8H80a8,8,0,0,1,0,16H64A8,8,0,0,1,56,152Zm112,0a8,8,0,0,1,8-8h16a8,8,0,0,1,0,16H176A8,8,0,0,1,168,152Z\"></path></svg> <span class=\"ml-2 text-xl font-bold text-gray-900\">Hedgestone Carwash</span></a></div><div class=\"hidden md:flex items-center space-x-8\"><a href=\"/#features\" class=\"text-accent hover:text-primary font-medium\">Features</a> <a href=\"/#pricing\" class=\"text-accent hover:text-primary font-medium\">Pricing</a> <a href=\"/about\" class=\"text-accent hover:text-primary font-medium\">About</a> <a href=\"/contact\" class=\"text-accent hover:text-primary font-medium\">Contact</a> <a href=\"/signup\" class=\"bg-primary text-primary-content px-4 py-2 rounded-lg hover:bg-primary/90 font-medium\">Register</a> <a href=\"/login\" class=\"text-accent border border-neutral px-4 py-2 rounded-lg hover:bg-neutral font-medium\">Login</a></div><div class=\"md:hidden\" x-data=\"{ mobileMenuOpen: false }\"><button @click=\"mobileMenuOpen = !mobileMenuOpen\" class=\"text-gray-700 hover:text-blue-600\"><svg class=\"h-6 w-6\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M4 6h16M4 12h16M4 18h16\"></path></svg></button><div x-show=\"mobileMenuOpen\" x-transition class=\"absolute top-16 right-4 bg-white rounded-lg shadow-lg p-4 w-56 z-50\"><a href=\"/\" class=\"block py-2 text-gray-700 hover:text-blue-600\">Home</a> <a href=\"/#features\" class=\"block py-2 text-gray-700 hover:text-blue-600\">Features</a> <a href=\"/#pricing\" class=\"block py-2 text-gray-700 hover:text-blue-600\">Pricing</a> <a href=\"/about\" class=\"block py-2 text-gray-700 hover:text-blue-600\">About</a> <a href=\"/contact\" class=\"block py-2 text-gray-700 hover:text-blue-600\">Contact</a> <a href=\"/signup\" class=\"block w-full text-left py-2 text-gray-700 hover:text-blue-600\">Register</a> <a href=\"/login\" class=\"block w-full text-left py-2 text-gray-700 hover:text-blue-600\">Login</a></div></div></div></div></nav>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<nav class=\"bg-white shadow-sm\"><div class=\"max-w-7xl mx-auto px-4 sm:px-6 lg:px-8\"><div class=\"flex justify-between items-center h-16\"><div class=\"flex items-center\"><a href=\"/\" class=\"flex items-center\"><svg class=\"h-8 w-8\" viewBox=\"0 0 256 256\" fill=\"#4299E1\"><path d=\"M240,112H229.2L201.42,49.5A16,16,0,0,0,186.8,40H69.2a16,16,0,0,0-14.62,9.5L26.8,112H16a8,8,0,0,0,0,16h8v80a16,16,0,0,0,16,16H64a16,16,0,0,0,16-16V192h96v16a16,16,0,0,0,16,16h24a16,16,0,0,0,16-16V128h8a8,8,0,0,0,0-16ZM69.2,56H186.8l24.89,56H44.31ZM64,208H40V192H64Zm128,0V192h24v16Zm24-32H40V128H216ZM56,152a8,8,0,0,1,8-8H80a8,8,0,0,1,0,16H64A8,8,0,0,1,56,152Zm112,0a8,8,0,0,1,8-8h16a8,8,0,0,1,0,16H176A8,8,0,0,1,168,152Z\"></path></svg> <span class=\"ml-2 text-xl font-bold text-gray-900\">Hedgestone Carwash</span></a></div><div class=\"hidden md:flex items-center space-x-8\"><a href=\"/#features\" class=\"text-accent hover:text-primary font-medium\">Features</a> <a href=\"/#pricing\" class=\"text-accent hover:text-primary font-medium\">Pricing</a> <a href=\"/about\" class=\"text-accent hover:text-primary font-medium\">About</a> <a href=\"/contact\" class=\"text-accent hover:text-primary font-medium\">Contact</a> <a href=\"/login\" class=\"text-accent border border-neutral px-4 py-2 rounded-lg hover:bg-neutral font-medium\">Log In / Sign Up</a></div><div class=\"md:hidden\" x-data=\"{ mobileMenuOpen: false }\"><button @click=\"mobileMenuOpen = !mobileMenuOpen\" class=\"text-gray-700 hover:text-blue-600\"><svg class=\"h-6 w-6\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M4 6h16M4 12h16M4 18h16\"></path></svg></button><div x-show=\"mobileMenuOpen\" x-transition class=\"absolute top-16 right-4 bg-white rounded-lg shadow-lg p-4 w-56 z-50\"><a href=\"/\" class=\"block py-2 text-gray-700 hover:text-blue-600\">Home</a> <a href=\"/#features\" class=\"block py-2 text-gray-700 hover:text-blue-600\">Features</a> <a href=\"/#pricing\" class=\"block py-2 text-gray-700 hover:text-blue-600\">Pricing</a> <a href=\"/about\" class=\"block py-2 text-gray-700 hover:text-blue-600\">About</a> <a href=\"/contact\" class=\"block py-2 text-gray-700 hover:text-blue-600\">Contact</a> <a href=\"/login\" class=\"block w-full text-left py-2 text-gray-700 hover:text-blue-600\">Log In / Sign Up</a></div></div></div></div></nav>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}